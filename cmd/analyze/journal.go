@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/http/api"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+)
+
+var notFound = api.TreasureNotFoundErr{}.Error()
+
+type depthStats struct {
+	digs, treasures int64
+}
+
+type tierStats struct {
+	licenses, spent, digs, treasures int64
+}
+
+type blockStats struct {
+	explores, errors, treasures int64
+	latency                     time.Duration
+}
+
+type scorePoint struct {
+	time  time.Time
+	score int64
+}
+
+// journalReport aggregates the events of a journal
+type journalReport struct {
+	depths map[int64]*depthStats
+	tiers  map[int64]*tierStats
+	blocks map[string]*blockStats
+
+	// license id -> price, to attribute digs to a price tier
+	licensePrices map[int64]int64
+
+	cashed, coins int64
+	score         []scorePoint
+	balance       int64
+}
+
+func newJournalReport() *journalReport {
+	return &journalReport{
+		depths:        map[int64]*depthStats{},
+		tiers:         map[int64]*tierStats{},
+		blocks:        map[string]*blockStats{},
+		licensePrices: map[int64]int64{},
+	}
+}
+
+// priceTier returns the lower bound of the power of two tier the price is in
+func priceTier(price int64) int64 {
+	tier := int64(1)
+	if price <= 0 {
+		return 0
+	}
+	for tier*2 <= price {
+		tier *= 2
+	}
+	return tier
+}
+
+func (r *journalReport) tier(price int64) *tierStats {
+	t := priceTier(price)
+	s, ok := r.tiers[t]
+	if !ok {
+		s = &tierStats{}
+		r.tiers[t] = s
+	}
+	return s
+}
+
+func (r *journalReport) track(t time.Time, delta int64) {
+	r.balance += delta
+	r.score = append(r.score, scorePoint{time: t, score: r.balance})
+}
+
+func (r *journalReport) add(e journal.Event) error {
+	switch e.Type {
+	case journal.DigEvent:
+		d := e.Dig
+		// other errors don't spend a license permit
+		if d.Error != "" && d.Error != notFound {
+			return nil
+		}
+		s, ok := r.depths[d.Depth]
+		if !ok {
+			s = &depthStats{}
+			r.depths[d.Depth] = s
+		}
+		s.digs++
+		s.treasures += int64(d.Treasures)
+		if price, ok := r.licensePrices[d.LicenseID]; ok {
+			t := r.tier(price)
+			t.digs++
+			t.treasures += int64(d.Treasures)
+		}
+	case journal.CashEvent:
+		if e.Cash.Error != "" {
+			return nil
+		}
+		r.cashed++
+		r.coins += int64(e.Cash.Coins)
+		r.track(e.Time, int64(e.Cash.Coins))
+	case journal.LicenseEvent:
+		l := e.License
+		if l.Error != "" {
+			return nil
+		}
+		r.licensePrices[l.ID] = l.Price
+		t := r.tier(l.Price)
+		t.licenses++
+		t.spent += l.Price
+		r.track(e.Time, -l.Price)
+	case journal.ExploreEvent:
+		area := e.Explore.Report.Area
+		key := fmt.Sprintf("%dx%d", area.SizeX, area.SizeY)
+		s, ok := r.blocks[key]
+		if !ok {
+			s = &blockStats{}
+			r.blocks[key] = s
+		}
+		s.explores++
+		if e.Explore.Error != "" {
+			s.errors++
+			return nil
+		}
+		s.treasures += e.Explore.Report.Amount
+		s.latency += e.Explore.Latency
+	}
+	return nil
+}
+
+func (r *journalReport) coinsPerTreasure() float64 {
+	if r.cashed == 0 {
+		return 0
+	}
+	return float64(r.coins) / float64(r.cashed)
+}
+
+func sortedKeys(m map[int64]bool) []int64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (r *journalReport) print(p *printer) {
+	p.section("treasures per depth")
+	p.row("depth", "digs", "treasures", "treasures/dig")
+	depths := map[int64]bool{}
+	for d := range r.depths {
+		depths[d] = true
+	}
+	for _, d := range sortedKeys(depths) {
+		s := r.depths[d]
+		p.row(d, s.digs, s.treasures, ratio(s.treasures, s.digs))
+	}
+
+	cpt := r.coinsPerTreasure()
+	p.section(fmt.Sprintf("coins per license price tier (%.2f coins per treasure)", cpt))
+	p.row("tier", "licenses", "spent", "digs", "treasures", "est. coins", "coins/spent")
+	tiers := map[int64]bool{}
+	for t := range r.tiers {
+		tiers[t] = true
+	}
+	for _, t := range sortedKeys(tiers) {
+		s := r.tiers[t]
+		coins := float64(s.treasures) * cpt
+		label := fmt.Sprintf("%d-%d", t, 2*t-1)
+		if t == 0 {
+			label = "free"
+		}
+		p.row(label, s.licenses, s.spent, s.digs, s.treasures, fmt.Sprintf("%.0f", coins), ratioF(coins, float64(s.spent)))
+	}
+
+	p.section("explore cost per block size")
+	p.row("block", "explores", "errors", "avg latency", "treasures/explore", "latency/treasure")
+	blocks := make([]string, 0, len(r.blocks))
+	for b := range r.blocks {
+		blocks = append(blocks, b)
+	}
+	sort.Strings(blocks)
+	for _, b := range blocks {
+		s := r.blocks[b]
+		ok := s.explores - s.errors
+		var avg, perTreasure time.Duration
+		if ok > 0 {
+			avg = s.latency / time.Duration(ok)
+		}
+		if s.treasures > 0 {
+			perTreasure = s.latency / time.Duration(s.treasures)
+		}
+		p.row(b, s.explores, s.errors, avg, ratio(s.treasures, ok), perTreasure)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/coin"
+)
+
+// ledgerReport traces the coins of the exported wallet ledger
+type ledgerReport struct {
+	credited, debited int64
+	byDepth           map[int64]int64
+	treasuresByDepth  map[int64]map[string]bool
+	byPrice           map[int64]int64
+	licensesByPrice   map[int64]map[int64]bool
+	score             []scorePoint
+}
+
+func newLedgerReport() *ledgerReport {
+	return &ledgerReport{
+		byDepth:          map[int64]int64{},
+		treasuresByDepth: map[int64]map[string]bool{},
+		byPrice:          map[int64]int64{},
+		licensesByPrice:  map[int64]map[int64]bool{},
+	}
+}
+
+func (r *ledgerReport) add(e coin.Entry) error {
+	switch e.Type {
+	case coin.CreditEntry:
+		r.credited++
+		r.byDepth[e.Depth]++
+		if r.treasuresByDepth[e.Depth] == nil {
+			r.treasuresByDepth[e.Depth] = map[string]bool{}
+		}
+		r.treasuresByDepth[e.Depth][e.Treasure] = true
+		r.score = append(r.score, scorePoint{time: e.Time, score: r.credited - r.debited})
+	case coin.DebitEntry:
+		r.debited++
+		r.byPrice[e.Price]++
+		if r.licensesByPrice[e.Price] == nil {
+			r.licensesByPrice[e.Price] = map[int64]bool{}
+		}
+		r.licensesByPrice[e.Price][e.License] = true
+		r.score = append(r.score, scorePoint{time: e.Time, score: r.credited - r.debited})
+	}
+	return nil
+}
+
+func (r *ledgerReport) print(p *printer) {
+	p.section("coins by source depth (ledger)")
+	p.row("depth", "treasures", "coins", "coins/treasure")
+	depths := map[int64]bool{}
+	for d := range r.byDepth {
+		depths[d] = true
+	}
+	for _, d := range sortedKeys(depths) {
+		treasures := int64(len(r.treasuresByDepth[d]))
+		p.row(d, treasures, r.byDepth[d], ratio(r.byDepth[d], treasures))
+	}
+
+	p.section("coins by license price (ledger)")
+	p.row("price", "licenses", "coins")
+	prices := map[int64]bool{}
+	for pr := range r.byPrice {
+		prices[pr] = true
+	}
+	for _, pr := range sortedKeys(prices) {
+		p.row(pr, len(r.licensesByPrice[pr]), r.byPrice[pr])
+	}
+	p.row("credited", r.credited)
+	p.row("debited", r.debited)
+	p.row("balance", r.credited-r.debited)
+}
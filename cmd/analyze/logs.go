@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/mertics"
+)
+
+// logEntry covers both the structured json logger output and the
+// older runLogger blobs printed by the standard logger.
+type logEntry struct {
+	Time         time.Time                    `json:"time"`
+	Msg          string                       `json:"msg"`
+	CurrentScore *int64                       `json:"current_score"`
+	Average      map[string]float64           `json:"average"`
+	Histograms   map[string]mertics.Histogram `json:"histograms"`
+	App          *mertics.Snapshot            `json:"app"`
+}
+
+const stdLogTimeLayout = "2006/01/02 15:04:05"
+
+// logReport collects the score and the license waiting time from the logs
+type logReport struct {
+	score []scorePoint
+	// the latest get_license_wait histogram, it sums up every wait so far
+	wait       mertics.Histogram
+	waitLogged time.Time
+	// the latest average get_lid_time, for logs without histograms
+	getLidTime   time.Duration
+	getLidLogged time.Time
+}
+
+func (r *logReport) add(e logEntry) {
+	if e.CurrentScore != nil {
+		r.score = append(r.score, scorePoint{time: e.Time, score: *e.CurrentScore})
+	}
+	average, histograms := e.Average, e.Histograms
+	if e.App != nil {
+		average, histograms = e.App.Average, e.App.Histograms
+	}
+	if h, ok := histograms["get_license_wait"]; ok {
+		r.wait = h
+		r.waitLogged = e.Time
+	}
+	if v, ok := average["get_lid_time"]; ok {
+		r.getLidTime = time.Duration(v)
+		r.getLidLogged = e.Time
+	}
+}
+
+// readLogs reads json log lines, any other line is an error since the
+// console format can't be parsed back
+func readLogs(r io.Reader, report *logReport) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var t time.Time
+		i := bytes.IndexByte(line, '{')
+		if i < 0 {
+			return notJSON(n)
+		}
+		// the standard logger prefixes lines with a date and a time
+		if i > 0 {
+			parsed, err := time.Parse(stdLogTimeLayout, string(bytes.TrimSpace(line[:i])))
+			if err != nil {
+				return notJSON(n)
+			}
+			t = parsed
+			line = line[i:]
+		}
+		var e logEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return fmt.Errorf("line %d: %v", n, err)
+		}
+		if e.Time.IsZero() {
+			e.Time = t
+		}
+		report.add(e)
+	}
+	return scanner.Err()
+}
+
+func notJSON(line int) error {
+	return fmt.Errorf(`line %d is not a json log entry, run the bot with "format": "json" in the logger config`, line)
+}
+
+func (r *logReport) print(p *printer) {
+	p.section("time lost waiting for licenses")
+	if !r.waitLogged.IsZero() {
+		total := time.Duration(r.wait.Sum * float64(time.Millisecond))
+		p.row("total get_license_wait", total.Round(time.Millisecond))
+		p.row("waits", r.wait.Count)
+		p.row("mean", time.Duration(r.wait.Mean*float64(time.Millisecond)).Round(time.Microsecond))
+		p.row("max", time.Duration(r.wait.Max*float64(time.Millisecond)).Round(time.Microsecond))
+		p.row("logged at", r.waitLogged.Format(time.RFC3339))
+		return
+	}
+	if r.getLidLogged.IsZero() {
+		p.row("no get_license_wait or get_lid_time in logs")
+		return
+	}
+	// older logs only have the average, the total is unknown
+	p.row("average get_lid_time", r.getLidTime)
+	p.row("logged at", r.getLidLogged.Format(time.RFC3339))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/mertics"
+)
+
+func TestReadLogsSumsLicenseWaits(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.New(buf, logger.Info, logger.JSON)
+	metrics := mertics.New(true)
+	for _, d := range []time.Duration{time.Second, 2 * time.Second, 500 * time.Millisecond} {
+		metrics.ObserveDuration("get_license_wait", d)
+		snapshot := metrics.Snapshot()
+		log.Info("status", logger.F("current_score", int64(d/time.Millisecond)))
+		log.Info("metrics", logger.F("histograms", snapshot.Histograms))
+	}
+
+	var report logReport
+	if err := readLogs(buf, &report); err != nil {
+		t.Fatal(err)
+	}
+	if total := time.Duration(report.wait.Sum * float64(time.Millisecond)); total != 3500*time.Millisecond {
+		t.Fatalf("total wait = %v, want 3.5s", total)
+	}
+	if report.wait.Count != 3 {
+		t.Fatalf("waits = %d, want 3", report.wait.Count)
+	}
+	if len(report.score) != 3 || report.score[2].score != 500 {
+		t.Fatalf("score = %+v", report.score)
+	}
+}
+
+func TestReadLogsStdLoggerBlobs(t *testing.T) {
+	logs := `2021/03/04 10:00:00 {"current_score":12,"app":{"average":{"get_lid_time":2000000}}}` + "\n"
+	var report logReport
+	if err := readLogs(strings.NewReader(logs), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.getLidTime != 2*time.Millisecond || len(report.score) != 1 || report.score[0].score != 12 {
+		t.Fatalf("report = %+v", report)
+	}
+	if !report.score[0].time.Equal(time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("time = %v", report.score[0].time)
+	}
+}
+
+func TestReadLogsRejectsConsoleFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logger.New(buf, logger.Info, logger.Console)
+	log.Info("status", logger.F("current_score", 1))
+	log.Info("metrics", logger.F("histograms", map[string]int{"a": 1}))
+	var report logReport
+	err := readLogs(buf, &report)
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("err = %v, want the first line rejected", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Command analyze builds a report of a finished run from its event journal
+// and/or the json log lines printed by the bot.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/coin"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+)
+
+var (
+	journalDir = flag.String("journal", "", "Journal directory")
+	logsPath   = flag.String("logs", "", "Log file with json lines")
+	ledgerPath = flag.String("ledger", "", "Exported wallet ledger")
+	csvPath    = flag.String("csv", "score.csv", "Where to write the score over time")
+	interval   = flag.Duration("interval", 10*time.Second, "Score sampling interval for journal and ledger runs")
+)
+
+type printer struct {
+	w *tabwriter.Writer
+}
+
+func (p *printer) section(title string) {
+	p.w.Flush()
+	fmt.Fprintf(p.w, "\n== %s\n", title)
+}
+
+func (p *printer) row(cols ...interface{}) {
+	for i, c := range cols {
+		if i > 0 {
+			fmt.Fprint(p.w, "\t")
+		}
+		fmt.Fprint(p.w, c)
+	}
+	fmt.Fprintln(p.w)
+}
+
+func ratio(a, b int64) string {
+	return ratioF(float64(a), float64(b))
+}
+
+func ratioF(a, b float64) string {
+	if b == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(a/b, 'f', 3, 64)
+}
+
+// sample keeps the last score of every interval
+func sample(points []scorePoint, every time.Duration) []scorePoint {
+	if len(points) == 0 || every <= 0 {
+		return points
+	}
+	var sampled []scorePoint
+	next := points[0].time.Truncate(every).Add(every)
+	for i, pt := range points {
+		if pt.time.Before(next) && i != len(points)-1 {
+			continue
+		}
+		sampled = append(sampled, pt)
+		next = pt.time.Truncate(every).Add(every)
+	}
+	return sampled
+}
+
+func writeScore(w io.Writer, points []scorePoint) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"time", "elapsed_seconds", "score"}); err != nil {
+		return err
+	}
+	for _, pt := range points {
+		err := out.Write([]string{
+			pt.time.Format(time.RFC3339),
+			strconv.FormatFloat(pt.time.Sub(points[0].time).Seconds(), 'f', 0, 64),
+			strconv.FormatInt(pt.score, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func main() {
+	flag.Parse()
+
+	if *journalDir == "" && *logsPath == "" && *ledgerPath == "" {
+		log.Fatalln("nothing to analyze, pass -journal, -logs and/or -ledger")
+	}
+
+	p := &printer{w: tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)}
+	var score []scorePoint
+
+	if *journalDir != "" {
+		report := newJournalReport()
+		if err := journal.ReadDir(*journalDir, report.add); err != nil {
+			log.Fatalln("failed to read journal:", err)
+		}
+		report.print(p)
+		score = sample(report.score, *interval)
+	}
+
+	if *ledgerPath != "" {
+		f, err := os.Open(*ledgerPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		report := newLedgerReport()
+		err = coin.ReadLedger(f, report.add)
+		f.Close()
+		if err != nil {
+			log.Fatalln("failed to read ledger:", err)
+		}
+		report.print(p)
+		// the ledger is exact, prefer it over the journal estimate
+		if len(report.score) > 0 {
+			score = sample(report.score, *interval)
+		}
+	}
+
+	if *logsPath != "" {
+		f, err := os.Open(*logsPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		report := &logReport{}
+		err = readLogs(f, report)
+		f.Close()
+		if err != nil {
+			log.Fatalln("failed to read logs:", err)
+		}
+		report.print(p)
+		// the logged score is the actual wallet, prefer it over the estimate
+		if len(report.score) > 0 {
+			score = report.score
+		}
+	}
+	p.w.Flush()
+
+	if len(score) == 0 {
+		return
+	}
+	f, err := os.Create(*csvPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	if err := writeScore(f, score); err != nil {
+		log.Fatalln("failed to write score:", err)
+	}
+	fmt.Printf("\nscore over time written to %s\n", *csvPath)
+}
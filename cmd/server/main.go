@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/app"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
-	"log"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 var configPath = flag.String("cfg", "config.json", "Application config")
@@ -21,14 +24,35 @@ func main() {
 	flag.Parse()
 	var cfg config.Config
 
+	log := logger.New(os.Stderr, logger.Info, logger.Console)
+
 	configFile, err := os.OpenFile(*configPath, os.O_RDONLY, 0755)
 
 	if err != nil {
-		log.Println("error while reading config file:", err)
+		log.Error("error while reading config file", logger.F("error", err))
+		return
+	}
+
+	if err := json.NewDecoder(configFile).Decode(&cfg); err != nil {
+		log.Error("error while decoding config file", logger.F("error", err))
 		return
 	}
 
-	json.NewDecoder(configFile).Decode(&cfg)
+	configured, err := logger.FromConfig(cfg.Logger)
+	if err != nil {
+		log.Error("failed to configure logger", logger.F("error", err))
+		return
+	}
+	log = configured
+
+	j, err := journal.New(cfg.Journal, func(err error) {
+		log.Error("failed to write journal", logger.F("error", err))
+	})
+	if err != nil {
+		log.Error("failed to open journal", logger.F("error", err))
+		return
+	}
+	defer j.Close()
 
 	ADDRESS := os.Getenv("ADDRESS")
 	PORT := 8000
@@ -40,6 +64,25 @@ func main() {
 
 	cfg.BaseURL = fmt.Sprintf("http://%s:%v", ADDRESS, PORT)
 
-	log.Printf("STARTING A SERVER (ADDRESS=%s, PORT=%v, SCHEMA=%s)\n", ADDRESS, PORT, SCHEMA)
-	app.New(cfg).Start(context.Background())
+	log.Info("starting",
+		logger.F("address", ADDRESS),
+		logger.F("port", PORT),
+		logger.F("schema", SCHEMA),
+	)
+	a, err := app.New(cfg, log, j)
+	if err != nil {
+		log.Error("failed to create app", logger.F("error", err))
+		return
+	}
+
+	// stop on a signal so the app can save what it learned
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		s := <-signals
+		log.Info("shutting down", logger.F("signal", s.String()))
+		cancel()
+	}()
+	a.Start(ctx)
 }
\ No newline at end of file
@@ -0,0 +1,343 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/economics"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/price_controller"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/optimizers"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/strategy"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/stats"
+)
+
+type treasure struct {
+	id      string
+	arm     *arm
+	license int64
+	depth   int64
+}
+
+// arm is a strategy with its own licenses, price controller and price,
+// depth and payback statistics. Without a/b testing the app runs a single
+// arm, with it the world is split between two arms like a checkerboard of
+// blocks and the license issuers are split evenly between them. The wallet
+// and the budget are shared.
+type arm struct {
+	name            string
+	strategy        strategy.Strategy
+	licenses        license.Pool
+	priceList       license.Pricer
+	priceController price_controller.Controller
+	economics       *economics.Estimator
+	depthOptimizer  *optimizers.DepthOptimizer
+	digModel        *optimizers.DigModel
+
+	// license issuers buying for the arm, guarded by App.issuersMu
+	issuers int
+
+	mu                                            sync.Mutex
+	bought, spent, digs, treasures, cashed, coins int64
+	treasuresPerLicense                           map[int64]int64
+	lastNet                                       int64
+	// net coins per second, one sample per interval
+	rates []float64
+}
+
+// newArm builds an arm, the price changes of its controller are journaled
+// if j isn't nil
+func newArm(name string, cfg config.Config, maxLicenses int, log *logger.Logger, j *journal.Journal, deps strategy.Deps) (*arm, error) {
+	pricer, err := license.NewPricer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := price_controller.FromConfig(cfg.App.PriceController, log.With(logger.F("component", "price_controller"), logger.F("arm", name)), j)
+	if err != nil {
+		return nil, err
+	}
+	a := &arm{
+		name:                name,
+		licenses:            license.NewPool(maxLicenses, cfg.App.License.Shards, log.With(logger.F("component", "licenses"), logger.F("arm", name))),
+		priceList:           pricer,
+		priceController:     pc,
+		economics:           economics.New(cfg.App.Economics),
+		depthOptimizer:      optimizers.NewDepthOptimizer(cfg),
+		digModel:            optimizers.NewDigModel(cfg.App.DigModel, cfg.App.World.Depth),
+		treasuresPerLicense: map[int64]int64{},
+	}
+	deps.Config = cfg
+	deps.PriceList = a.priceList
+	deps.PriceController = a.priceController
+	deps.DepthOptimizer = a.depthOptimizer
+	deps.DigModel = a.digModel
+	deps.Log = deps.Log.With(logger.F("arm", name))
+	s, err := strategy.New(cfg.App.Strategy, deps)
+	if err != nil {
+		return nil, err
+	}
+	a.strategy = s
+	return a, nil
+}
+
+// abOverrides are the fields of the "app" section an arm has its own copy
+// of, nil if all of the field is, otherwise the fields of it that are
+var abOverrides = map[string][]string{
+	"min_treasures_per_block": nil,
+	"strategy":                nil,
+	"price_controller":        nil,
+	"economics":               nil,
+	"dig_model":               nil,
+	"world":                   {"depth_optimizer"},
+	"license":                 {"pricer", "thompson", "price_list", "free", "shards"},
+}
+
+// checkOverrides rejects overrides of the fields the arms share
+func checkOverrides(overrides json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(overrides, &fields); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		supported, ok := abOverrides[name]
+		if !ok {
+			return fmt.Errorf("a/b override %q is not supported", name)
+		}
+		if supported == nil {
+			continue
+		}
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(value, &nested); err != nil {
+			return fmt.Errorf("a/b override %q: %v", name, err)
+		}
+		for field := range nested {
+			if !contains(supported, field) {
+				return fmt.Errorf("a/b override %q is not supported", name+"."+field)
+			}
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// newArms builds the control arm and, if a/b testing is enabled, the
+// experiment arm. The experiment config is the app config with the
+// overrides applied, license slots are split between the arms. Only the
+// control arm journals its prices.
+func newArms(cfg config.Config, log *logger.Logger, j *journal.Journal, deps strategy.Deps) ([]*arm, error) {
+	ab := cfg.App.AB
+	if !ab.Enabled {
+		a, err := newArm("a", cfg, cfg.App.License.MaxAmount, log, j, deps)
+		if err != nil {
+			return nil, err
+		}
+		return []*arm{a}, nil
+	}
+	experiment := cfg
+	if len(ab.Overrides) > 0 {
+		if err := checkOverrides(ab.Overrides); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(ab.Overrides, &experiment.App); err != nil {
+			return nil, err
+		}
+	}
+	if ab.Strategy != "" {
+		experiment.App.Strategy = ab.Strategy
+	}
+	slots := cfg.App.License.MaxAmount / 2
+	if slots < 1 {
+		slots = 1
+	}
+	a, err := newArm("a", cfg, slots, log, j, deps)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newArm("b", experiment, slots, log, nil, deps)
+	if err != nil {
+		return nil, err
+	}
+	return []*arm{a, b}, nil
+}
+
+// armAt returns the arm the cell belongs to
+func (app *App) armAt(x, y int64) *arm {
+	if len(app.arms) == 1 {
+		return app.arms[0]
+	}
+	world, block := app.config.App.World, app.config.App.Block
+	i := (x-int64(world.SX))/int64(block.Width) + (y-int64(world.SY))/int64(block.Height)
+	return app.arms[i%2]
+}
+
+// issuerArm returns the arm a license issuer buys its next license for.
+// A starting issuer, with no current arm, joins the arm with the fewest
+// issuers, a running one moves there if its own arm has two more, so the
+// split holds however the pool is resized.
+func (app *App) issuerArm(current *arm) *arm {
+	app.issuersMu.Lock()
+	defer app.issuersMu.Unlock()
+	fewest := app.arms[0]
+	for _, a := range app.arms[1:] {
+		if a.issuers < fewest.issuers {
+			fewest = a
+		}
+	}
+	if current != nil && current.issuers-fewest.issuers < 2 {
+		return current
+	}
+	if current != nil {
+		current.issuers--
+	}
+	fewest.issuers++
+	return fewest
+}
+
+// issuerLeft removes a leaving license issuer from its arm
+func (app *App) issuerLeft(a *arm) {
+	app.issuersMu.Lock()
+	a.issuers--
+	app.issuersMu.Unlock()
+}
+
+func (a *arm) licenseBought(price int64) {
+	a.mu.Lock()
+	a.bought++
+	a.spent += price
+	a.mu.Unlock()
+}
+
+func (a *arm) dug(licenseID int64, treasures int) {
+	a.mu.Lock()
+	a.digs++
+	a.treasures += int64(treasures)
+	a.treasuresPerLicense[licenseID] += int64(treasures)
+	a.mu.Unlock()
+}
+
+func (a *arm) cashedTreasure(coins int) {
+	a.mu.Lock()
+	a.cashed++
+	a.coins += int64(coins)
+	a.mu.Unlock()
+}
+
+func (a *arm) sample(elapsed time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	net := a.coins - a.spent
+	a.rates = append(a.rates, float64(net-a.lastNet)/elapsed.Seconds())
+	a.lastNet = net
+}
+
+type armReport struct {
+	Name                string  `json:"name"`
+	Licenses            int64   `json:"licenses"`
+	Spent               int64   `json:"spent"`
+	Digs                int64   `json:"digs"`
+	Treasures           int64   `json:"treasures"`
+	Cashed              int64   `json:"cashed"`
+	Coins               int64   `json:"coins"`
+	CoinsPerSecond      float64 `json:"coins_per_second"`
+	TreasuresPerLicense float64 `json:"treasures_per_license"`
+
+	rates, perLicense []float64
+}
+
+func (a *arm) report() armReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r := armReport{
+		Name:      a.name,
+		Licenses:  a.bought,
+		Spent:     a.spent,
+		Digs:      a.digs,
+		Treasures: a.treasures,
+		Cashed:    a.cashed,
+		Coins:     a.coins,
+		rates:     append([]float64(nil), a.rates...),
+	}
+	for _, n := range a.treasuresPerLicense {
+		r.perLicense = append(r.perLicense, float64(n))
+	}
+	r.CoinsPerSecond = stats.Mean(r.rates)
+	r.TreasuresPerLicense = stats.Mean(r.perLicense)
+	return r
+}
+
+type abReport struct {
+	Arms []armReport `json:"arms"`
+	// state both arms use, a difference in one of them affects the other
+	Shared []string `json:"shared"`
+	// two-sided p-values of the difference between the arms
+	CoinsPerSecondP      float64 `json:"coins_per_second_p"`
+	TreasuresPerLicenseP float64 `json:"treasures_per_license_p"`
+}
+
+func (app *App) abReport() abReport {
+	r := abReport{
+		Shared:               []string{"wallet", "budget"},
+		CoinsPerSecondP:      1,
+		TreasuresPerLicenseP: 1,
+	}
+	for _, a := range app.arms {
+		r.Arms = append(r.Arms, a.report())
+	}
+	if len(r.Arms) == 2 {
+		_, r.CoinsPerSecondP = stats.Welch(r.Arms[0].rates, r.Arms[1].rates)
+		_, r.TreasuresPerLicenseP = stats.Welch(r.Arms[0].perLicense, r.Arms[1].perLicense)
+	}
+	return r
+}
+
+func (app *App) logABReport() {
+	r := app.abReport()
+	app.log.Info("a/b report",
+		logger.F("arms", r.Arms),
+		logger.F("shared", r.Shared),
+		logger.F("coins_per_second_p", r.CoinsPerSecondP),
+		logger.F("treasures_per_license_p", r.TreasuresPerLicenseP),
+	)
+}
+
+// runAB samples the coin rate of the arms and logs the final report at the
+// end of the game or once ctx is done.
+func (app *App) runAB(ctx context.Context) {
+	if len(app.arms) < 2 {
+		return
+	}
+	interval := app.config.App.AB.SampleInterval.Parse()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			app.logABReport()
+			return
+		case now := <-t.C:
+			for _, a := range app.arms {
+				a.sample(now.Sub(last))
+			}
+			last = now
+			if app.clock.remaining() == 0 {
+				app.logABReport()
+				return
+			}
+		}
+	}
+}
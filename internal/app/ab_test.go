@@ -0,0 +1,78 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/strategy"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+func TestCheckOverrides(t *testing.T) {
+	for overrides, ok := range map[string]bool{
+		`{"min_treasures_per_block": 2, "economics": {"margin": 1}}`: true,
+		`{"world": {"depth_optimizer": {"k": 3}}}`:                   true,
+		`{"license": {"pricer": "thompson", "shards": 4}}`:           true,
+		`{"cashers": 10}`:                             false,
+		`{"budget": {"enabled": true}}`:               false,
+		`{"world": {"depth": 5}}`:                     false,
+		`{"license": {"max_amount": 5}}`:              false,
+		`{"license": {"price_table": {"path": "x"}}}`: false,
+	} {
+		if err := checkOverrides(json.RawMessage(overrides)); (err == nil) != ok {
+			t.Errorf("checkOverrides(%s) = %v", overrides, err)
+		}
+	}
+}
+
+func TestNewArmsOwnControllers(t *testing.T) {
+	var cfg config.Config
+	cfg.App.License.MaxAmount = 4
+	cfg.App.Economics.Enabled = true
+	cfg.App.AB.Enabled = true
+	cfg.App.AB.Overrides = json.RawMessage(`{"min_treasures_per_block": 2}`)
+	arms, err := newArms(cfg, logger.Nop(), nil, strategy.Deps{Log: logger.Nop()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arms) != 2 {
+		t.Fatalf("%d arms, want 2", len(arms))
+	}
+	if arms[0].priceController == arms[1].priceController || arms[0].economics == arms[1].economics {
+		t.Fatal("arms share a price controller or an economics estimator")
+	}
+
+	cfg.App.AB.Overrides = json.RawMessage(`{"cashers": 10}`)
+	if _, err := newArms(cfg, logger.Nop(), nil, strategy.Deps{Log: logger.Nop()}); err == nil {
+		t.Fatal("an unsupported override was accepted")
+	}
+}
+
+func TestIssuerArmKeepsTheSplit(t *testing.T) {
+	a, b := &arm{name: "a"}, &arm{name: "b"}
+	app := &App{arms: []*arm{a, b}}
+	issuers := make([]*arm, 4)
+	for i := range issuers {
+		issuers[i] = app.issuerArm(nil)
+	}
+	if a.issuers != 2 || b.issuers != 2 {
+		t.Fatalf("split %d/%d, want 2/2", a.issuers, b.issuers)
+	}
+
+	// both issuers of one arm leave, the first remaining one to take a job
+	// moves over
+	for _, i := range []int{0, 2} {
+		app.issuerLeft(issuers[i])
+	}
+	if issuers[1] != issuers[3] {
+		t.Fatalf("issuers 1 and 3 buy for %s and %s", issuers[1].name, issuers[3].name)
+	}
+	moved := app.issuerArm(issuers[1])
+	if moved == issuers[1] || a.issuers != 1 || b.issuers != 1 {
+		t.Fatalf("split %d/%d after rebalancing, want 1/1", a.issuers, b.issuers)
+	}
+	if app.issuerArm(issuers[3]) != issuers[3] {
+		t.Fatal("a balanced issuer switched arms")
+	}
+}
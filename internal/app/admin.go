@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/optimizers"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+type queuesState struct {
+	ExploredAreas   int `json:"explored_areas"`
+	UnexploredAreas int `json:"unexplored_areas"`
+	Treasures       int `json:"treasures"`
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (app *App) workersState() map[string]poolState {
+	state := map[string]poolState{}
+	for name, p := range app.workers.byName {
+		state[name] = p.state()
+	}
+	return state
+}
+
+// handleWorkers serves GET /workers and
+// POST /workers/{class}/{pause|resume|resize?size=N}
+func (app *App) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/workers"), "/"), "/")
+	if len(parts) == 1 && parts[0] == "" {
+		writeJSON(w, app.workersState())
+		return
+	}
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := app.workers.byName[parts[0]]
+	if !ok {
+		http.Error(w, "unknown worker class", http.StatusNotFound)
+		return
+	}
+	switch parts[1] {
+	case "pause":
+		p.pause()
+	case "resume":
+		p.resume()
+	case "resize":
+		size, err := strconv.Atoi(r.URL.Query().Get("size"))
+		if err != nil || size < 0 {
+			http.Error(w, "size must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		p.resize(size)
+		app.log.Info("workers resized", logger.F("class", p.name), logger.F("size", size))
+		writeJSON(w, p.state())
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	app.log.Info("workers "+parts[1]+"d", logger.F("class", p.name))
+	writeJSON(w, p.state())
+}
+
+func (app *App) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, app.config)
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, app.metrics.Snapshot())
+	})
+	mux.HandleFunc("/queues", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, queuesState{
+			ExploredAreas:   app.exploredAreas.Size(),
+			UnexploredAreas: app.unexploredAreas.Len(),
+			Treasures:       len(app.treasures),
+		})
+	})
+	mux.HandleFunc("/licenses", func(w http.ResponseWriter, r *http.Request) {
+		stats := map[string]interface{}{}
+		for _, a := range app.arms {
+			stats[a.name] = a.licenses.Stats()
+		}
+		writeJSON(w, stats)
+	})
+	mux.HandleFunc("/density", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, app.unexploredAreas.Stats())
+	})
+	mux.HandleFunc("/digmodel", func(w http.ResponseWriter, r *http.Request) {
+		reports := map[string][]optimizers.DepthEstimate{}
+		for _, a := range app.arms {
+			reports[a.name] = a.digModel.Report()
+		}
+		writeJSON(w, reports)
+	})
+	mux.HandleFunc("/leases", func(w http.ResponseWriter, r *http.Request) {
+		audits := map[string]license.Audit{}
+		for _, a := range app.arms {
+			audits[a.name] = a.licenses.Audit(app.leaseMaxAge())
+		}
+		writeJSON(w, audits)
+	})
+	mux.HandleFunc("/prices", func(w http.ResponseWriter, r *http.Request) {
+		prices := map[string]int64{}
+		lists := map[string]interface{}{}
+		tiers := map[string]interface{}{}
+		for _, a := range app.arms {
+			prices[a.name] = a.priceController.GetPrice()
+			lists[a.name] = a.priceList.Map()
+			if p, ok := a.priceList.(*license.PriceList); ok {
+				free, paid := p.Tiers()
+				tiers[a.name] = map[string]license.TierStats{"free": free, "paid": paid}
+			}
+		}
+		writeJSON(w, map[string]interface{}{
+			"current_price": prices,
+			"price_lists":   lists,
+			"tiers":         tiers,
+		})
+	})
+	mux.HandleFunc("/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"total":   runtime.NumGoroutine(),
+			"workers": app.workersState(),
+		})
+	})
+	mux.HandleFunc("/budget", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, app.budget.Stats())
+	})
+	mux.HandleFunc("/ledger", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, app.ledger.Totals())
+	})
+	mux.HandleFunc("/economics", func(w http.ResponseWriter, r *http.Request) {
+		reports := map[string]interface{}{}
+		for _, a := range app.arms {
+			reports[a.name] = a.economics.Report()
+		}
+		writeJSON(w, reports)
+	})
+	mux.HandleFunc("/ab", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, app.abReport())
+	})
+	mux.HandleFunc("/workers", app.handleWorkers)
+	mux.HandleFunc("/workers/", app.handleWorkers)
+	return mux
+}
+
+// runAdmin serves the admin api until ctx is done
+func (app *App) runAdmin(ctx context.Context) {
+	server := &http.Server{
+		Addr:    app.config.Admin.Address,
+		Handler: app.adminHandler(),
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	app.log.Info("admin api started", logger.F("address", server.Addr))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		app.log.Error("admin api failed", logger.F("error", err))
+	}
+}
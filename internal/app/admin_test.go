@@ -0,0 +1,65 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+func TestAdminWorkers(t *testing.T) {
+	jobs, done := make(chan int), make(chan int, 10)
+	p := newChanPool(jobs, done)
+	defer p.stop()
+	app := &App{
+		log:     logger.Nop(),
+		workers: &workers{byName: map[string]*pool{"test": p}},
+	}
+	handler := app.adminHandler()
+	do := func(method, path string) (*httptest.ResponseRecorder, poolState) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		var s poolState
+		if w.Code == http.StatusOK {
+			json.NewDecoder(w.Body).Decode(&s)
+		}
+		return w, s
+	}
+
+	if w, s := do(http.MethodPost, "/workers/test/resize?size=3"); w.Code != http.StatusOK || s.Size != 3 {
+		t.Fatalf("resize: %d, %+v", w.Code, s)
+	}
+	if w, s := do(http.MethodPost, "/workers/test/pause"); w.Code != http.StatusOK || !s.Paused {
+		t.Fatalf("pause: %d, %+v", w.Code, s)
+	}
+	if w, s := do(http.MethodPost, "/workers/test/resume"); w.Code != http.StatusOK || s.Paused {
+		t.Fatalf("resume: %d, %+v", w.Code, s)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workers", nil))
+	var state map[string]poolState
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+	if state["test"].Size != 3 {
+		t.Fatalf("workers state = %+v", state)
+	}
+
+	for path, code := range map[string]int{
+		"/workers/test/resize?size=-1": http.StatusBadRequest,
+		"/workers/test/resize":         http.StatusBadRequest,
+		"/workers/other/pause":         http.StatusNotFound,
+		"/workers/test/explode":        http.StatusNotFound,
+		"/workers/test/pause/now":      http.StatusNotFound,
+	} {
+		if w, _ := do(http.MethodPost, path); w.Code != code {
+			t.Errorf("POST %s = %d, want %d", path, w.Code, code)
+		}
+	}
+	if w, _ := do(http.MethodGet, "/workers/test/pause"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET of an action = %d", w.Code)
+	}
+}
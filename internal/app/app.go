@@ -2,19 +2,19 @@ package app
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
 	area2 "github.com/RomanIschenko/golden-rush-mailru/internal/entities/area"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/coin"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
-	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/price_controller"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/money"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/http/api"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/http/models"
-	"github.com/RomanIschenko/golden-rush-mailru/internal/optimizers"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/strategy"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/util/mertics"
-	"log"
-	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -22,32 +22,53 @@ type App struct {
 	wallet        *coin.Manager
 	exploredAreas *area2.Queue
 	api           *api.API
-	licenses      *license.Manager
 	metrics       *mertics.Metrics
+	log           *logger.Logger
+	journal       *journal.Journal
 	config        config.Config
-	priceList     *license.PriceList
 
-	priceController *price_controller.PriceController
-
-	depthOptimizer *optimizers.DepthOptimizer
-	unexploredAreas chan area2.Area
-	treasures chan string
+	unexploredAreas *area2.DensityMap
+	treasures chan treasure
+	workers *workers
+	clock *gameClock
+	ledger *coin.Ledger
+	budget *money.Manager
+	// the first arm is the control one
+	arms      []*arm
+	issuersMu sync.Mutex
 }
-func (app *App) runCasher() {
-	for t := range app.treasures {
-		app.cash(t)
+func (app *App) runCasher(w *worker) {
+	log := app.log.With(logger.F("worker", "casher"), logger.F("worker_id", w.id))
+	for w.next() {
+		select {
+		case t := <-app.treasures:
+			w.took()
+			app.cash(log, t)
+		case <-w.idle.Done():
+		}
 	}
 }
 
-func (app *App) cash(t string) (coins int64) {
-	data, err := app.api.Cash(t)
+func (app *App) cash(log *logger.Logger, t treasure) (coins int64) {
+	wallet := models.AcquireWallet()
+	defer models.ReleaseWallet(wallet)
+
+	s := time.Now()
+	err := app.api.Cash(t.id, wallet)
+	app.journal.Cashed(t.id, len(*wallet), time.Since(s), err)
 	if err != nil {
 		app.metrics.IncCounter("cash_errors")
+		log.Debug("cash failed", logger.F("treasure", t.id), logger.F("error", err))
 		return
 	}
+	data := *wallet
 	coins += int64(len(data))
 
-	app.priceController.AddCoins(int64(len(data)))
+	t.arm.priceController.AddCoins(int64(len(data)))
+	t.arm.cashedTreasure(len(data))
+	t.arm.economics.Cashed(t.license, len(data))
+	t.arm.digModel.Cashed(t.depth, len(data))
+	app.ledger.Credit(data, t.id, t.depth)
 
 	app.metrics.IncCounter("cash_ok")
 	app.wallet.Add(data...)
@@ -55,68 +76,71 @@ func (app *App) cash(t string) (coins int64) {
 	return
 }
 
-func (app *App) initUnexploredAreas() {
-	areas := area2.GenerateAreas(
-		int64(app.config.App.World.SX),
-		int64(app.config.App.World.SY),
-		int64(app.config.App.World.Width),
-		int64(app.config.App.World.Height),
-		int64(app.config.App.Block.Width),
-		int64(app.config.App.Block.Height),
-	)
-
-	rand.Shuffle(len(areas), func(i, j int) {
-		t := areas[i]
-		areas[i] = areas[j]
-		areas[j] = t
-	})
-
-	for _, a := range areas {
-		app.unexploredAreas <- a
-	}
-}
-
 func (app *App) runLogger() {
 	//app.metrics.AddMax("licenses_deleted", float64(app.licenses.DeletedLicenses()))
+	log := app.log.With(logger.F("component", "reporter"))
 	t := time.NewTicker(app.config.Logger.Interval.Parse())
 	for range t.C {
-		m := map[string]interface{}{
-			"current_score": app.wallet.Amount(),
-			"best_depth": app.depthOptimizer.Best(),
-			"app":      app.metrics.Snapshot(),
-			//"poll_api": app.api.Metrics().Snapshot(),
-			"price_list": app.priceList.Map(),
-		}
-		if data, err := json.Marshal(m); err == nil {
-			log.Println(string(data))
+		log.Info("status",
+			logger.F("current_score", app.wallet.Amount()),
+			logger.F("best_depth", app.arms[0].depthOptimizer.Best()),
+		)
+		snapshot := app.metrics.Snapshot()
+		log.Info("metrics",
+			logger.F("counters", snapshot.Counters),
+			logger.F("max", snapshot.Max),
+			logger.F("average", snapshot.Average),
+			logger.F("histograms", snapshot.Histograms),
+		)
+		for _, a := range app.arms {
+			log.Info("price list",
+				logger.F("arm", a.name),
+				logger.F("prices", a.priceList.Map()),
+				logger.F("free_digs", a.priceController.FreeDigs()),
+			)
+			if log.Enabled(logger.Debug) {
+				log.Debug("economics", logger.F("arm", a.name), logger.F("report", a.economics.Report()))
+			}
 		}
 	}
 }
 
-func (app *App) runExplorer() {
-	for {
-		select {
-		case a := <-app.unexploredAreas:
-			report, err := app.api.Explore(models.Area{
-				PosX:  a.X,
-				PosY:  a.Y,
-				SizeX: a.W,
-				SizeY: a.H,
-			})
-
-			if err != nil {
-				app.metrics.IncCounter("explore_errors")
-				continue
-			}
-			app.metrics.IncCounter("explore_ok")
-			a.Treasures = report.Amount
-			app.metrics.AddAverage("treasures_per_block", float64(a.Treasures))
-			if a.Treasures < int64(app.config.App.MinTreasuresPerBlock) {
-				continue
-			}
-			app.metrics.AddCounter("treasures", float64(a.Treasures))
-			app.exploredAreas.Push(a)
+func (app *App) runExplorer(w *worker) {
+	log := app.log.With(logger.F("worker", "explorer"), logger.F("worker_id", w.id))
+	for w.next() {
+		a, err := app.unexploredAreas.PopContext(w.idle)
+		if err != nil {
+			continue
+		}
+		w.took()
+		strategy := app.armAt(a.X, a.Y).strategy
+		if !strategy.ExploreArea(a) {
+			continue
+		}
+		req := models.Area{
+			PosX:  a.X,
+			PosY:  a.Y,
+			SizeX: a.W,
+			SizeY: a.H,
+		}
+		s := time.Now()
+		report, err := app.api.Explore(req)
+		app.journal.Explored(models.Report{Area: req, Amount: report.Amount}, time.Since(s), false, err)
+
+		if err != nil {
+			app.metrics.IncCounter("explore_errors")
+			log.Debug("explore failed", logger.F("area", a), logger.F("error", err))
+			continue
+		}
+		app.metrics.IncCounter("explore_ok")
+		a.Treasures = report.Amount
+		app.unexploredAreas.Explored(a)
+		app.metrics.AddAverage("treasures_per_block", float64(a.Treasures))
+		if !strategy.KeepArea(a) {
+			continue
 		}
+		app.metrics.AddCounter("treasures", float64(a.Treasures))
+		app.exploredAreas.Push(a)
 	}
 }
 
@@ -125,13 +149,34 @@ type location struct {
 	NoMore bool
 }
 
-func (app *App) runDigger() {
+func (app *App) runDigger(w *worker) {
+	log := app.log.With(logger.F("worker", "digger"), logger.F("worker_id", w.id))
 	areaChannel := make(chan area2.Area)
 	locationChannel := make(chan location, 3)
+	// stops the location producer once the digger leaves, even in the
+	// middle of an area
+	quit := make(chan struct{})
+	defer close(quit)
 	go func(app *App, areaChannel <-chan area2.Area, locationChannel chan <-location) {
-		for a := range areaChannel {
+		send := func(loc location) bool {
+			select {
+			case locationChannel <- loc:
+				return true
+			case <-quit:
+				return false
+			}
+		}
+		for {
+			var a area2.Area
+			select {
+			case a = <-areaChannel:
+			case <-quit:
+				return
+			}
 			if a.Treasures <= 0 {
-				locationChannel <- location{NoMore: true}
+				if !send(location{NoMore: true}) {
+					return
+				}
 				continue
 			}
 			for x := a.X; x < a.X + a.W; x++ {
@@ -142,12 +187,15 @@ func (app *App) runDigger() {
 					if a.Treasures <= 0 {
 						break
 					}
-					res, err := app.api.Explore(models.Area{
+					req := models.Area{
 						PosX:  x,
 						PosY:  y,
 						SizeX: 1,
 						SizeY: 1,
-					})
+					}
+					s := time.Now()
+					res, err := app.api.Explore(req)
+					app.journal.Explored(models.Report{Area: req, Amount: res.Amount}, time.Since(s), false, err)
 
 					if err != nil {
 						continue
@@ -159,49 +207,105 @@ func (app *App) runDigger() {
 
 					a.Treasures -= res.Amount
 
-					locationChannel <- location{
+					if !send(location{
 						X:         x,
 						Y:         y,
 						Treasures: res.Amount,
 						NoMore:    false,
+					}) {
+						return
 					}
 				}
 			}
-			locationChannel <- location{NoMore: true}
+			if !send(location{NoMore: true}) {
+				return
+			}
 		}
 	}(app, areaChannel, locationChannel)
 
-	for {
-		area := app.exploredAreas.Pop()
+	for w.next() {
+		area, err := app.exploredAreas.PopContext(w.idle)
+		if err != nil {
+			continue
+		}
+		w.took()
 		areaChannel <- area
+		arm := app.armAt(area.X, area.Y)
+		areaLog := log.With(logger.F("area", area), logger.F("arm", arm.name))
+		areaLog.Debug("digging area")
 
-		var licenseHandle license.Handle
+		var (
+			licenseHandle license.Handle
+			// permits of one license for the rest of the cell
+			permits license.Batch
+		)
 
 		for loc := range locationChannel {
 			if loc.NoMore {
 				break
 			}
 			depth := int64(1)
-			maxDepth := app.depthOptimizer.Next()
+			maxDepth := arm.strategy.MaxDepth(loc.X, loc.Y)
+			stopped := false
 			for depth <= maxDepth {
+				plan := arm.strategy.PlanDepth(depth, loc.Treasures)
+				if plan > maxDepth {
+					plan = maxDepth
+				}
+				if plan < depth {
+					// the next dig isn't worth a permit
+					stopped = true
+					break
+				}
 				if !licenseHandle.Active() {
-					getLidStartTime := time.Now()
-					licenseHandle = app.licenses.Get()
-					app.metrics.AddAverage("get_lid_time", float64(time.Since(getLidStartTime)))
+					h, ok := permits.Next()
+					if !ok {
+						b, err := app.getPermits(w, arm, plan-depth+1)
+						if err == context.DeadlineExceeded {
+							app.metrics.IncCounter("get_license_timeouts")
+							areaLog.Warn("no license in time, retrying", logger.F("timeout", app.config.App.License.GetTimeout))
+							continue
+						}
+						if err != nil {
+							// the pool shrank or stopped, workers that stay
+							// wait again
+							if w.next() {
+								continue
+							}
+							// the handle is closed already, give back what
+							// the cell didn't use
+							permits.Release()
+							return
+						}
+						permits = b
+						h, _ = permits.Next()
+					}
+					licenseHandle = h
 				}
 				s := time.Now()
 				// x, y, depth, licenseHandle.ID()
-				result, err := app.api.Dig(models.Dig{
+				treasures := models.AcquireTreasureList()
+				dig := models.Dig{
 					Depth:     depth,
 					LicenseID: licenseHandle.ID(),
 					PosX:      loc.X,
 					PosY:      loc.Y,
-				})
+				}
+				err := app.api.Dig(dig, treasures)
+				result := *treasures
 				timePerDig := time.Since(s)
+				app.journal.Dug(dig, timePerDig, len(result), err)
+				if _, empty := err.(api.TreasureNotFoundErr); err == nil || empty {
+					arm.strategy.Dug(depth, len(result), timePerDig)
+					arm.dug(licenseHandle.ID(), len(result))
+					arm.economics.Dug(depth, len(result))
+					arm.digModel.Dug(depth, loc.Treasures, len(result), timePerDig)
+				}
 				app.metrics.AddAverage(fmt.Sprintf("dig_time_at_%d", depth), float64(timePerDig))
 				app.metrics.AddCounter(fmt.Sprintf("treasures_%d_feet_deep", depth), float64(len(result)))
 				app.metrics.AddAverage(fmt.Sprintf("treasures_%d_feet_deep", depth), float64(len(result)))
 				if err != nil {
+					models.ReleaseTreasureList(treasures)
 					app.metrics.IncCounter("dig_errors")
 					switch err.(type) {
 					case api.TreasureNotFoundErr:
@@ -219,6 +323,13 @@ func (app *App) runDigger() {
 						depth++
 					default:
 						app.metrics.IncCounter("default_dig_errors")
+						areaLog.Warn("dig failed",
+							logger.F("license_id", licenseHandle.ID()),
+							logger.F("x", loc.X),
+							logger.F("y", loc.Y),
+							logger.F("depth", depth),
+							logger.F("error", err),
+						)
 					}
 					continue
 				}
@@ -226,15 +337,21 @@ func (app *App) runDigger() {
 				licenseHandle.Close()
 				loc.Treasures -= int64(len(result))
 				for _, t := range result {
-					app.treasures <- t
+					app.treasures <- treasure{id: t, arm: arm, license: dig.LicenseID, depth: depth}
 				}
+				models.ReleaseTreasureList(treasures)
 				app.metrics.IncCounter("treasures_put")
 				depth++
 				if loc.Treasures <= 0 {
 					break
 				}
 			}
-			if loc.Treasures > 0 {
+			// the digs the cell didn't need go to other diggers
+			permits.Release()
+			if loc.Treasures > 0 && stopped {
+				app.metrics.IncCounter("stopped_cells")
+				app.metrics.AddCounter("left_treasures", float64(loc.Treasures))
+			} else if loc.Treasures > 0 {
 				app.metrics.AddCounter("lost_treasures", float64(loc.Treasures))
 			}
 			app.metrics.IncCounter("finished_cells")
@@ -245,96 +362,181 @@ func (app *App) runDigger() {
 func (app *App) reRunPreExplorers(interval time.Duration) {
 	t := time.NewTicker(interval)
 	for range t.C {
-		deadline := time.Now().Add(app.config.App.PreExplorationTimeout.Parse())
-		for i := 0; i < 30; i++ {
-			go app.preExplore(deadline, 1000)
+		if app.clock.phase() >= lateGame {
+			return
 		}
+		deadline := time.Now().Add(app.config.App.PreExplorationTimeout.Parse())
+		app.workers.preExplorers.spawn(30, func(w *worker) {
+			app.preExplore(w, deadline, 1000)
+		})
 	}
 }
 
-func (app *App) preExplore(deadline time.Time, max int) {
+func (app *App) preExplore(w *worker, deadline time.Time, max int) {
 	c := 0
-	for {
-		select {
-		case ua := <-app.unexploredAreas:
-			if time.Now().After(deadline) {
-				app.metrics.AddAverage("preExplorations", float64(c))
-				return
-			}
-			if c >= max {
-				return
-			}
+	for w.next() {
+		ua, err := app.unexploredAreas.PopContext(w.idle)
+		if err != nil {
+			continue
+		}
+		w.took()
+		if time.Now().After(deadline) {
+			app.metrics.AddAverage("preExplorations", float64(c))
+			return
+		}
+		if c >= max {
+			return
+		}
+		strategy := app.armAt(ua.X, ua.Y).strategy
+		if !strategy.ExploreArea(ua) {
+			continue
+		}
 
-			s := time.Now()
-			rep, err := app.api.ExploreDeadline(deadline, models.Area{
-				PosX:  ua.X,
-				PosY:  ua.Y,
-				SizeX: ua.W,
-				SizeY: ua.H,
-			})
-			app.metrics.IncCounter("total_pre_explore")
-			if err != nil {
-				app.metrics.IncCounter("pre_explore_errors")
-				continue
-			}
+		req := models.Area{
+			PosX:  ua.X,
+			PosY:  ua.Y,
+			SizeX: ua.W,
+			SizeY: ua.H,
+		}
+		s := time.Now()
+		rep, err := app.api.ExploreDeadline(deadline, req)
+		app.journal.Explored(models.Report{Area: req, Amount: rep.Amount}, time.Since(s), true, err)
+		app.metrics.IncCounter("total_pre_explore")
+		if err != nil {
+			app.metrics.IncCounter("pre_explore_errors")
+			continue
+		}
 
-			app.metrics.AddAverage(fmt.Sprintf("%dby%d_explore", ua.W, ua.H), float64(time.Since(s)))
-			app.metrics.IncCounter("pre_explore_ok")
-			ua.Treasures = rep.Amount
-			if ua.Treasures < int64(app.config.App.MinTreasuresPerBlock) {
-				continue
-			}
-			c++
-			app.exploredAreas.PushWithoutBlocking(ua)
+		app.metrics.AddAverage(fmt.Sprintf("%dby%d_explore", ua.W, ua.H), float64(time.Since(s)))
+		app.metrics.IncCounter("pre_explore_ok")
+		ua.Treasures = rep.Amount
+		app.unexploredAreas.Explored(ua)
+		if !strategy.KeepArea(ua) {
+			continue
 		}
+		c++
+		app.exploredAreas.PushWithoutBlocking(ua)
 	}
 }
-func (app *App) runLicenseIssuer() {
-	for {
-		handle := app.licenses.RequestAdd()
-
-		maxCoins := app.priceController.GetPrice()
+// getPermits waits for up to size dig permits of one of the arm's licenses
+// until the app stops or the configured timeout passes
+// getPermits waits for permits until the worker has to check whether it
+// leaves or GetTimeout passes
+func (app *App) getPermits(w *worker, arm *arm, size int64) (license.Batch, error) {
+	ctx := w.idle
+	if timeout := app.config.App.License.GetTimeout; timeout != "" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout.Parse())
+		defer cancel()
+	}
+	s := time.Now()
+	b, err := arm.licenses.GetBatch(ctx, int(size))
+	wait := time.Since(s)
+	app.metrics.AddAverage("get_lid_time", float64(wait))
+	app.metrics.ObserveDuration("get_license_wait", wait)
+	if err == nil {
+		app.metrics.AddAverage("permit_batch_size", float64(b.Len()))
+	}
+	return b, err
+}
 
-		price := app.priceList.Next(maxCoins)
+func (app *App) runLicenseIssuer(w *worker) {
+	log := app.log.With(logger.F("worker", "license_issuer"), logger.F("worker_id", w.id))
+	var arm *arm
+	defer func() {
+		if arm != nil {
+			app.issuerLeft(arm)
+		}
+	}()
+	for w.next() {
+		arm = app.issuerArm(arm)
+		s := time.Now()
+		handle, err := arm.licenses.RequestAddContext(w.idle)
+		app.metrics.ObserveDuration("request_add_wait", time.Since(s))
+		if err != nil {
+			continue
+		}
+		w.took()
 
-		//fmt.Println("ready to spend:", price.CoinsAmount, price.Experimental(), maxCoins)
+		var price license.Price
+		// a paid license can't pay back this close to the end
+		if app.clock.phase() < endGame {
+			price = arm.strategy.LicensePrice()
+		}
+		// experiments are for learning, only regular purchases have to pay back
+		if !price.Experimental() && !arm.economics.Worth(price.CoinsAmount) {
+			app.metrics.IncCounter("unprofitable_licenses_skipped")
+			price = license.Price{}
+		}
 
-		coins := app.wallet.Pop(int(price.CoinsAmount))
+		coins, ok := app.budget.Take(price.CoinsAmount, price.Experimental())
+		if !ok {
+			app.metrics.IncCounter("over_budget_licenses")
+			if price.Experimental() {
+				// give the amount back to the experiments
+				price.Failed = true
+				arm.strategy.LicenseIssued(price)
+			}
+			price = license.Price{}
+		}
 		price.RealAmount = int64(len(coins))
+		s = time.Now()
 		res, err := app.api.IssueLicenses(coins)
+		price.Latency = time.Since(s)
+		app.journal.LicenseIssued(res.ID, price.RealAmount, res.DigAllowed-res.DigUsed, price.Experimental(), err)
 		if err != nil {
 			handle.Fail()
 			price.Failed = true
-			app.priceList.Commit(price)
-			app.wallet.Add(coins...)
+			arm.strategy.LicenseIssued(price)
+			app.budget.Refund(coins, price.Experimental())
 			app.metrics.IncCounter(err.Error())
+			log.Debug("license issue failed", logger.F("price", price.CoinsAmount), logger.F("error", err))
 			continue
 		}
 
-		app.priceController.DeleteCoins(int64(len(coins)))
+		arm.priceController.DeleteCoins(int64(len(coins)))
+		app.ledger.Debit(coins, res.ID, price.RealAmount)
+		if price.Free() {
+			app.metrics.IncCounter("free_licenses")
+			arm.priceController.AddFreeDigs(res.DigAllowed - res.DigUsed)
+		}
 
 		app.metrics.AddCounter("spent_on_license", float64(price.CoinsAmount))
 		app.metrics.AddAverage("license_price", float64(price.CoinsAmount))
-		id := res.ID
 		digs := res.DigAllowed - res.DigUsed
 		price.Failed = false
 		price.Digs = digs
-		app.priceList.Commit(price)
+		arm.strategy.LicenseIssued(price)
+		arm.licenseBought(price.RealAmount)
+		arm.economics.LicenseIssued(res.ID, price.RealAmount, digs)
+		arm.digModel.LicenseIssued(price.RealAmount, digs)
+		licenseLog := log.With(logger.F("license_id", res.ID))
 		if digs <= 0 {
 			handle.Fail()
+			licenseLog.Warn("license has no digs", logger.F("price", price.CoinsAmount))
 			continue
 		}
-		if err := handle.Ok(id, digs); err != nil {
+		if err := handle.Ok(res.ID, digs); err != nil {
 			app.metrics.IncCounter("license_add_errors")
+			licenseLog.Warn("failed to add license", logger.F("error", err))
+			continue
 		}
+		licenseLog.Debug("license issued",
+			logger.F("price", price.CoinsAmount),
+			logger.F("digs", digs),
+			logger.F("experimental", price.Experimental()),
+		)
 	}
 }
 
 func (app *App) Start(ctx context.Context) {
+	app.api.SetContext(ctx)
 	go app.runLogger()
-	go app.initUnexploredAreas()
+	if app.config.Admin.Enabled {
+		go app.runAdmin(ctx)
+	}
 	if err := app.api.HealthCheck(); err != nil {
-		log.Println("failed to get response from health check")
+		app.log.Error("failed to get response from health check", logger.F("error", err))
 		return
 
 	}
@@ -343,54 +545,91 @@ func (app *App) Start(ctx context.Context) {
 
 	if app.config.App.Block.Auto {
 		s := time.Now()
-		log.Println("auto_block_size_time:", time.Since(s))
-		log.Println("(auto_size)", app.config.App.Block.Width, app.config.App.Block.Height)
+		app.log.Info("auto block size",
+			logger.F("time", time.Since(s).String()),
+			logger.F("width", app.config.App.Block.Width),
+			logger.F("height", app.config.App.Block.Height),
+		)
 	}
 
 	preexplorationDeadline := time.Now().Add(app.config.App.PreExplorationTimeout.Parse())
 
-	log.Println("preexploration deadline", preexplorationDeadline)
+	app.log.Info("preexploration started", logger.F("deadline", preexplorationDeadline))
 
-	for i := 0; i < app.config.App.PreExploreWorkers; i++ {
-		go app.preExplore(preexplorationDeadline, app.config.App.MaxBlocksPerPreExploreWorker)
-	}
+	app.workers.preExplorers.spawn(app.config.App.PreExploreWorkers, func(w *worker) {
+		app.preExplore(w, preexplorationDeadline, app.config.App.MaxBlocksPerPreExploreWorker)
+	})
 
 	time.Sleep(app.config.App.PreExplorationTimeout.Parse())
 
-	log.Println("finished preexploration")
+	app.log.Info("finished preexploration")
 
 	go app.reRunPreExplorers(time.Minute * 2)
 
-	for i := 0; i < app.config.App.LicenseIssuers; i++ {
-		go app.runLicenseIssuer()
+	app.workers.licenseIssuers.resize(app.config.App.LicenseIssuers)
+	app.workers.cashers.resize(app.config.App.Cashers)
+	app.workers.explorers.resize(app.config.App.Explorers)
+	app.workers.diggers.resize(app.config.App.Diggers)
+	if app.config.App.Autoscaler.Enabled {
+		go newAutoscaler(app).run(ctx)
 	}
-
-	for i := 0; i < app.config.App.Cashers; i++ {
-		go app.runCasher()
+	go app.runClock(ctx)
+	go app.runAB(ctx)
+	go app.runLeaseAudit(ctx)
+	for _, a := range app.arms {
+		go a.priceController.Run(app.config.App.PriceController.Interval.Parse())
+	}
+	<-ctx.Done()
+	app.workers.stop()
+	for _, a := range app.arms {
+		a.priceController.Stop()
 	}
+	app.savePriceTable()
+	app.exportLedger()
+}
 
-	for i := 0; i < app.config.App.Explorers; i++ {
-		go app.runExplorer()
+// exportLedger writes the ledger for the analyzer
+func (app *App) exportLedger() {
+	path := app.config.Ledger.Export
+	if app.ledger == nil || path == "" {
+		return
 	}
-	for i := 0; i < app.config.App.Diggers; i++ {
-		go app.runDigger()
+	if err := app.ledger.ExportFile(path); err != nil {
+		app.log.Error("failed to export ledger", logger.F("path", path), logger.F("error", err))
+		return
 	}
-	go app.priceController.Run(time.Millisecond * 250)
-	<-ctx.Done()
+	app.log.Info("ledger exported", logger.F("path", path))
 }
 
-func New(config config.Config) *App {
-	return &App{
+func New(config config.Config, log *logger.Logger, j *journal.Journal) (*App, error) {
+	metrics := mertics.New(config.Logger.Enabled)
+	app := &App{
 		wallet:          coin.NewManager(),
 		exploredAreas:   area2.NewQueue(60),
-		api:             api.New(config),
-		treasures:       make(chan string, 100000),
-		licenses:        license.NewManager(config.App.License.MaxAmount),
-		metrics:         mertics.New(config.Logger.Enabled),
+		api:             api.New(config, metrics, log.With(logger.F("component", "api"))),
+		treasures:       make(chan treasure, 100000),
+		metrics:         metrics,
+		log:             log,
+		journal:         j,
 		config:          config,
-		priceList:       license.NewPriceList(config),
-		priceController: price_controller.New(),
-		depthOptimizer:  optimizers.NewDepthOptimizer(config),
-		unexploredAreas: make(chan area2.Area, 1000000),
+		unexploredAreas: area2.NewDensityMap(config),
+	}
+	app.budget = money.New(config.App.Budget, app.wallet)
+	if config.Ledger.Enabled {
+		app.ledger = coin.NewLedger()
+	}
+	app.workers = newWorkers(app)
+	app.clock = newGameClock(config.App.Game, time.Now())
+	arms, err := newArms(config, log, j, strategy.Deps{
+		Log:    log.With(logger.F("component", "strategy")),
+		Wallet: app.wallet,
+	})
+	if err != nil {
+		return nil, err
+	}
+	app.arms = arms
+	if err := app.seedPriceTable(); err != nil {
+		return nil, err
 	}
+	return app, nil
 }
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/area"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/strategy"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/mertics"
+)
+
+// fakeExplore finds a treasure in every explored cell
+type fakeExplore struct {
+	calls int64
+}
+
+func (f *fakeExplore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/explore" {
+		http.NotFound(w, r)
+		return
+	}
+	atomic.AddInt64(&f.calls, 1)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"area":{"posX":0,"posY":0,"sizeX":1,"sizeY":1},"amount":1}`))
+}
+
+// newStarvedApp returns an app whose diggers have explored areas but no
+// license is ever issued
+func newStarvedApp(t *testing.T) (*App, *fakeExplore) {
+	var cfg config.Config
+	cfg.App.World.Width = 10
+	cfg.App.World.Height = 10
+	cfg.App.World.Depth = 10
+	cfg.App.World.DepthOptimizer.K = 1
+	cfg.App.World.DepthOptimizer.G = 1
+	cfg.App.Block.Width = 1
+	cfg.App.Block.Height = 1
+	cfg.App.License.MaxAmount = 1
+	server := &fakeExplore{}
+	metrics := mertics.New(false)
+	app := &App{
+		api:           newTestAPI(t, &cfg, metrics, server),
+		metrics:       metrics,
+		log:           logger.Nop(),
+		exploredAreas: area.NewQueue(10),
+		treasures:     make(chan treasure, 10),
+	}
+	app.config = cfg
+	app.clock = newGameClock(cfg.App.Game, time.Now())
+	arms, err := newArms(cfg, logger.Nop(), nil, strategy.Deps{Log: logger.Nop()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.arms = arms
+	app.unexploredAreas = area.NewDensityMap(cfg)
+	app.workers = newWorkers(app)
+	t.Cleanup(app.workers.stop)
+	return app, server
+}
+
+// startStarvedDiggers starts n diggers and waits until they wait for permits
+func startStarvedDiggers(t *testing.T, app *App, server *fakeExplore, n int) {
+	for i := 0; i < n; i++ {
+		app.exploredAreas.Push(area.Area{X: int64(i), Y: 0, W: 1, H: 1, Treasures: 1})
+	}
+	app.workers.diggers.resize(n)
+	waitFor(t, "diggers to explore their cells", func() bool {
+		return atomic.LoadInt64(&server.calls) == int64(n) && app.exploredAreas.Size() == 0
+	})
+	// the cells are explored, the diggers are waiting for permits
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestStarvedDiggersLeaveOnShrink(t *testing.T) {
+	app, server := newStarvedApp(t)
+	diggers := app.workers.diggers
+	startStarvedDiggers(t, app, server, 3)
+
+	diggers.resize(1)
+	waitFor(t, "starved diggers to leave", func() bool { return diggers.state().Running == 1 })
+	if s := diggers.state(); s.Size != 1 || s.Leaving != 0 {
+		t.Fatalf("diggers after shrinking = %+v", s)
+	}
+
+	// the digger that stays still waits for permits and leaves on stop
+	diggers.stop()
+	waitFor(t, "the last digger to stop", func() bool { return diggers.state().Running == 0 })
+}
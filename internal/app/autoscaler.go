@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// stage is one step of the pipeline the autoscaler sizes
+type stage struct {
+	pool *pool
+	// jobs waiting for the stage
+	backlog func() int
+
+	lastJobs int64
+	// smoothed jobs per worker per second, 0 until measured
+	rate float64
+}
+
+// measure updates the throughput of the stage from the jobs taken since the last call
+func (s *stage) measure(elapsed time.Duration) poolState {
+	state := s.pool.state()
+	done := state.Jobs - s.lastJobs
+	s.lastJobs = state.Jobs
+	if state.Running == 0 || elapsed <= 0 {
+		return state
+	}
+	rate := float64(done) / elapsed.Seconds() / float64(state.Running)
+	if s.rate == 0 {
+		s.rate = rate
+	} else {
+		s.rate = 0.7*s.rate + 0.3*rate
+	}
+	return state
+}
+
+// demand estimates the worker seconds needed to drain the backlog, ok is
+// false if the stage has work but no throughput was measured yet
+func (s *stage) demand() (demand float64, ok bool) {
+	backlog := s.backlog()
+	if backlog == 0 {
+		return 0, true
+	}
+	if s.rate <= 0 {
+		return 0, false
+	}
+	return float64(backlog) / s.rate, true
+}
+
+// autoscaler splits a fixed worker budget between explorers, diggers and
+// cashers in proportion to the time each stage needs to drain its backlog.
+type autoscaler struct {
+	app    *App
+	config config.AutoscalerConfig
+	log    *logger.Logger
+	stages []*stage
+}
+
+func newAutoscaler(app *App) *autoscaler {
+	return &autoscaler{
+		app:    app,
+		config: app.config.App.Autoscaler,
+		log:    app.log.With(logger.F("component", "autoscaler")),
+		stages: []*stage{
+			{
+				pool: app.workers.explorers,
+				// explorers are useful while the diggers' queue has room
+				backlog: func() int {
+					free := app.exploredAreas.BufferSize() - app.exploredAreas.Size()
+					if unexplored := app.unexploredAreas.Len(); unexplored < free {
+						free = unexplored
+					}
+					if free < 0 {
+						return 0
+					}
+					return free
+				},
+			},
+			{
+				pool:    app.workers.diggers,
+				backlog: app.exploredAreas.Size,
+			},
+			{
+				pool:    app.workers.cashers,
+				backlog: func() int { return len(app.treasures) },
+			},
+		},
+	}
+}
+
+func (a *autoscaler) budget() int {
+	if a.config.Budget > 0 {
+		return a.config.Budget
+	}
+	cfg := a.app.config.App
+	return cfg.Explorers + cfg.Diggers + cfg.Cashers
+}
+
+// targets splits the budget, every stage keeps at least MinWorkers. A stage
+// with work but no measured throughput keeps its workers, at least one,
+// until it has a sample, the others share the rest by demand.
+func (a *autoscaler) targets(budget int, sizes []int) []int {
+	targets := make([]int, len(a.stages))
+	demands := make([]float64, len(a.stages))
+	measured := make([]bool, len(a.stages))
+	var total float64
+	free := budget
+	for i, s := range a.stages {
+		demands[i], measured[i] = s.demand()
+		if !measured[i] {
+			targets[i] = maxInt(sizes[i], maxInt(a.config.MinWorkers, 1))
+			free -= targets[i]
+			continue
+		}
+		total += demands[i]
+		free -= a.config.MinWorkers
+	}
+	if total == 0 {
+		// nothing measured is queued, keep the current split
+		for i := range targets {
+			if measured[i] {
+				targets[i] = sizes[i]
+			}
+		}
+		return targets
+	}
+	if free < 0 {
+		free = 0
+	}
+	assigned := 0
+	largest := -1
+	for i := range a.stages {
+		if measured[i] {
+			targets[i] = a.config.MinWorkers + int(demands[i]/total*float64(free))
+			if largest < 0 || demands[i] > demands[largest] {
+				largest = i
+			}
+		}
+		assigned += targets[i]
+	}
+	// rounding leftovers go to the stage with the largest demand
+	if left := budget - assigned; left > 0 {
+		targets[largest] += left
+	}
+	return targets
+}
+
+func maxInt(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func minInt(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+// step moves at most Step workers towards the targets, the total never
+// exceeds the budget.
+func (a *autoscaler) step(budget int, sizes, targets []int) []int {
+	next := append([]int(nil), sizes...)
+	total := 0
+	give, take := 0, 0
+	for i := range sizes {
+		total += sizes[i]
+		if targets[i]-sizes[i] > targets[give]-sizes[give] {
+			give = i
+		}
+		if targets[i]-sizes[i] < targets[take]-sizes[take] {
+			take = i
+		}
+	}
+	switch {
+	case total > budget:
+		next[take] -= minInt(a.config.Step, total-budget)
+	case total < budget:
+		next[give] += minInt(a.config.Step, budget-total)
+	default:
+		n := minInt(a.config.Step, minInt(targets[give]-sizes[give], sizes[take]-targets[take]))
+		if n > 0 {
+			next[give] += n
+			next[take] -= n
+		}
+	}
+	for i := range next {
+		if next[i] < a.config.MinWorkers {
+			next[i] = a.config.MinWorkers
+		}
+	}
+	return next
+}
+
+func (a *autoscaler) tick(elapsed time.Duration) {
+	budget := a.budget()
+	sizes := make([]int, len(a.stages))
+	for i, s := range a.stages {
+		sizes[i] = s.measure(elapsed).Size
+	}
+	next := a.step(budget, sizes, a.targets(budget, sizes))
+	for i, s := range a.stages {
+		if next[i] == sizes[i] {
+			continue
+		}
+		s.pool.resize(next[i])
+		a.log.Debug("stage resized",
+			logger.F("class", s.pool.name),
+			logger.F("from", sizes[i]),
+			logger.F("to", next[i]),
+			logger.F("backlog", s.backlog()),
+			logger.F("rate", s.rate),
+		)
+	}
+}
+
+// run rebalances the stages every interval until ctx is done
+func (a *autoscaler) run(ctx context.Context) {
+	interval := a.config.Interval.Parse()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for _, s := range a.stages {
+		s.measure(0)
+	}
+	last := time.Now()
+	a.log.Info("autoscaler started", logger.F("budget", a.budget()))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if a.app.clock.phase() >= cashOut {
+				// the clock owns the pools from now on
+				return
+			}
+			a.tick(now.Sub(last))
+			last = now
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func newTestAutoscaler(minWorkers, step int, backlogs []int, rates []float64) *autoscaler {
+	a := &autoscaler{config: config.AutoscalerConfig{MinWorkers: minWorkers, Step: step}}
+	for i := range backlogs {
+		backlog := backlogs[i]
+		a.stages = append(a.stages, &stage{
+			backlog: func() int { return backlog },
+			rate:    rates[i],
+		})
+	}
+	return a
+}
+
+func TestAutoscalerTargetsFollowDemand(t *testing.T) {
+	// the second stage needs three times the worker seconds of the first
+	a := newTestAutoscaler(1, 2, []int{10, 30, 0}, []float64{1, 1, 1})
+	got := a.targets(11, []int{4, 4, 3})
+	if want := []int{3, 7, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("targets = %v, want %v", got, want)
+	}
+}
+
+func TestAutoscalerUnmeasuredStageKeepsWorkers(t *testing.T) {
+	// a stage with work and no measured rate keeps its workers, the
+	// others share the rest
+	a := newTestAutoscaler(1, 2, []int{10, 5, 0}, []float64{1, 0, 1})
+	got := a.targets(9, []int{3, 3, 3})
+	if want := []int{5, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("targets = %v, want %v", got, want)
+	}
+	// and gets a worker to measure it with
+	if got := a.targets(9, []int{4, 0, 5}); got[1] != 1 {
+		t.Fatalf("targets = %v, want a worker for the unmeasured stage", got)
+	}
+	// with nothing else queued the rest keep their split
+	a = newTestAutoscaler(1, 2, []int{0, 5, 0}, []float64{1, 0, 1})
+	if got := a.targets(9, []int{4, 2, 3}); !reflect.DeepEqual(got, []int{4, 2, 3}) {
+		t.Fatalf("targets = %v, want the current split", got)
+	}
+}
+
+func TestAutoscalerIdleKeepsSplit(t *testing.T) {
+	a := newTestAutoscaler(1, 2, []int{0, 0, 0}, []float64{1, 1, 1})
+	if got := a.targets(9, []int{2, 3, 4}); !reflect.DeepEqual(got, []int{2, 3, 4}) {
+		t.Fatalf("targets = %v with nothing queued", got)
+	}
+}
+
+func TestAutoscalerStep(t *testing.T) {
+	a := newTestAutoscaler(1, 2, nil, nil)
+	for _, c := range []struct {
+		budget               int
+		sizes, targets, want []int
+	}{
+		// moves at most Step workers from the largest surplus to the largest deficit
+		{9, []int{6, 2, 1}, []int{1, 7, 1}, []int{4, 4, 1}},
+		// over the budget, workers leave the largest surplus
+		{6, []int{6, 2, 1}, []int{1, 4, 1}, []int{4, 2, 1}},
+		// under the budget, workers join the largest deficit
+		{9, []int{1, 1, 1}, []int{1, 7, 1}, []int{1, 3, 1}},
+		// never below MinWorkers
+		{2, []int{1, 1, 1}, []int{1, 1, 0}, []int{1, 1, 1}},
+	} {
+		if got := a.step(c.budget, c.sizes, c.targets); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("step(%d, %v, %v) = %v, want %v", c.budget, c.sizes, c.targets, got, c.want)
+		}
+	}
+}
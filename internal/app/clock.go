@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// phase is the stage of the game, later phases stop spending on things
+// that won't pay back before the deadline.
+type phase int
+
+const (
+	playing phase = iota
+	// pre-exploration is stopped
+	lateGame
+	// only free licenses are issued
+	endGame
+	// everything but cashing is stopped
+	cashOut
+)
+
+func (p phase) String() string {
+	switch p {
+	case lateGame:
+		return "late_game"
+	case endGame:
+		return "end_game"
+	case cashOut:
+		return "cash_out"
+	default:
+		return "playing"
+	}
+}
+
+// gameClock tells the phase from the time left until the end of the game
+type gameClock struct {
+	config   config.GameConfig
+	deadline time.Time
+}
+
+func newGameClock(cfg config.GameConfig, start time.Time) *gameClock {
+	c := &gameClock{config: cfg}
+	if cfg.Duration != "" {
+		c.deadline = start.Add(cfg.Duration.Parse())
+	}
+	return c
+}
+
+// remaining returns the time left, a negative value if the game has no deadline
+func (c *gameClock) remaining() time.Duration {
+	if c.deadline.IsZero() {
+		return -1
+	}
+	left := time.Until(c.deadline)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+func (c *gameClock) before(d config.Duration) bool {
+	return d != "" && c.remaining() >= 0 && c.remaining() <= d.Parse()
+}
+
+func (c *gameClock) phase() phase {
+	switch {
+	case c.before(c.config.CashOutBefore):
+		return cashOut
+	case c.before(c.config.StopPaidLicensesBefore):
+		return endGame
+	case c.before(c.config.StopPreExploreBefore):
+		return lateGame
+	default:
+		return playing
+	}
+}
+
+// enterPhase stops the workers that aren't needed anymore
+func (app *App) enterPhase(p phase) {
+	app.log.Info("game phase changed",
+		logger.F("phase", p.String()),
+		logger.F("remaining", app.clock.remaining().String()),
+	)
+	if p >= endGame {
+		// no more paid licenses, the prices are final
+		app.savePriceTable()
+	}
+	if p >= lateGame {
+		app.workers.preExplorers.resize(0)
+	}
+	if p >= cashOut {
+		app.workers.explorers.resize(0)
+		app.workers.diggers.resize(0)
+		app.workers.licenseIssuers.resize(0)
+		if n := app.config.App.Game.CashOutCashers; n > app.workers.cashers.state().Size {
+			app.workers.cashers.resize(n)
+		}
+	}
+}
+
+// runClock follows the game phases until ctx is done
+func (app *App) runClock(ctx context.Context) {
+	if app.clock.deadline.IsZero() {
+		return
+	}
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	current := playing
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if p := app.clock.phase(); p != current {
+				current = p
+				app.enterPhase(p)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func TestGameClockPhases(t *testing.T) {
+	cfg := config.GameConfig{
+		Duration:               "10m",
+		StopPreExploreBefore:   "5m",
+		StopPaidLicensesBefore: "2m",
+		CashOutBefore:          "1m",
+	}
+	for _, c := range []struct {
+		elapsed time.Duration
+		want    phase
+	}{
+		{0, playing},
+		{6 * time.Minute, lateGame},
+		{8*time.Minute + 30*time.Second, endGame},
+		{9*time.Minute + 30*time.Second, cashOut},
+		{time.Hour, cashOut},
+	} {
+		clock := newGameClock(cfg, time.Now().Add(-c.elapsed))
+		if got := clock.phase(); got != c.want {
+			t.Errorf("phase after %v = %v, want %v", c.elapsed, got, c.want)
+		}
+	}
+}
+
+func TestGameClockWithoutDeadline(t *testing.T) {
+	clock := newGameClock(config.GameConfig{CashOutBefore: "1m"}, time.Now())
+	if clock.remaining() >= 0 {
+		t.Fatalf("remaining = %v without a deadline", clock.remaining())
+	}
+	if clock.phase() != playing {
+		t.Fatalf("phase = %v without a deadline", clock.phase())
+	}
+}
+
+func TestCashOutStopsStarvedDiggers(t *testing.T) {
+	app, server := newStarvedApp(t)
+	startStarvedDiggers(t, app, server, 2)
+
+	app.enterPhase(cashOut)
+	for _, p := range []*pool{app.workers.diggers, app.workers.explorers, app.workers.licenseIssuers} {
+		waitFor(t, p.name+" to stop", func() bool { return p.state().Running == 0 })
+	}
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// leaseMaxAge is how long a digger may hold a permit before it is reported
+func (app *App) leaseMaxAge() time.Duration {
+	if age := app.config.App.License.Audit.MaxAge; age != "" {
+		return age.Parse()
+	}
+	return 0
+}
+
+// runLeaseAudit audits the license pools every interval until ctx is done
+func (app *App) runLeaseAudit(ctx context.Context) {
+	interval := app.config.App.License.Audit.Interval
+	if interval == "" {
+		return
+	}
+	t := time.NewTicker(interval.Parse())
+	defer t.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			app.auditLeases(last)
+			last = now
+		}
+	}
+}
+
+// auditLeases logs the double closes since the last audit and the leaked
+// permits, and checks the digs used of every live license against the
+// server: it has to count at least the closed permits and at most the
+// handed out ones.
+func (app *App) auditLeases(since time.Time) {
+	before := make([]license.Audit, len(app.arms))
+	for i, a := range app.arms {
+		before[i] = a.licenses.Audit(0)
+	}
+	listed := time.Now()
+	server, err := app.api.ListLicenses()
+	check := err == nil
+	if err != nil {
+		app.log.Debug("failed to list licenses", logger.F("error", err))
+	} else if len(server) == 0 && liveBefore(before, listed) {
+		// a broken list rather than every lease out of sync at once
+		check = false
+		app.metrics.IncCounter("empty_license_lists")
+		app.log.Warn("server listed no licenses while leases are live")
+	}
+	used := make(map[int64]int64, len(server))
+	for _, l := range server {
+		used[l.ID] = l.DigUsed
+	}
+
+	for i, a := range app.arms {
+		log := app.log.With(logger.F("arm", a.name))
+		after := a.licenses.Audit(app.leaseMaxAge())
+		for _, d := range after.Recent {
+			if d.Time.After(since) {
+				log.Warn("license handle closed twice",
+					logger.F("license_id", d.License),
+					logger.F("permit", d.Permit),
+					logger.F("taken", d.Taken),
+					logger.F("closed", d.Closed),
+				)
+			}
+		}
+		for _, l := range after.Leaks {
+			app.metrics.IncCounter("leaked_permits")
+			log.Warn("license permit held too long",
+				logger.F("license_id", l.License),
+				logger.F("permit", l.Permit),
+				logger.F("age", l.Age),
+				logger.F("stack", l.Stack),
+			)
+		}
+		if !check {
+			continue
+		}
+
+		taken := make(map[int64]int64, len(after.Leases))
+		for _, l := range after.Leases {
+			taken[l.ID] = l.Taken
+		}
+		for _, l := range before[i].Leases {
+			max, live := taken[l.ID]
+			if !live || l.Issued.After(listed) {
+				// released or issued while listing
+				continue
+			}
+			u, ok := used[l.ID]
+			if ok && l.Closed <= u && u <= max {
+				continue
+			}
+			app.metrics.IncCounter("lease_mismatches")
+			log.Warn("license digs used don't match the server",
+				logger.F("license_id", l.ID),
+				logger.F("closed", l.Closed),
+				logger.F("taken", max),
+				logger.F("server_used", u),
+				logger.F("known_to_server", ok),
+			)
+		}
+	}
+}
+
+// liveBefore reports whether any lease was issued before t
+func liveBefore(audits []license.Audit, t time.Time) bool {
+	for _, a := range audits {
+		for _, l := range a.Leases {
+			if !l.Issued.After(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
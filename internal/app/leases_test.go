@@ -0,0 +1,130 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/http/api"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/mertics"
+)
+
+// fakeLicenses serves GET /licenses with the body set last
+type fakeLicenses struct {
+	mu   sync.Mutex
+	body string
+}
+
+func (f *fakeLicenses) set(body string) {
+	f.mu.Lock()
+	f.body = body
+	f.mu.Unlock()
+}
+
+func (f *fakeLicenses) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/licenses" || r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(f.body))
+}
+
+// newTestAPI points cfg and the api at an in-process server
+func newTestAPI(t *testing.T, cfg *config.Config, metrics *mertics.Metrics, handler http.Handler) *api.API {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	cfg.BaseURL = ts.URL
+	pc := config.PollerConfig{TimeOut: "1s", Interval: "0", MaxIters: 1}
+	cfg.Api.DigPoller = pc
+	cfg.Api.CashPoller = pc
+	cfg.Api.ExplorePoller = pc
+	cfg.Api.HealthCheckPoller = pc
+	cfg.Api.IssueLicensePoller = pc
+	return api.New(*cfg, metrics, logger.Nop())
+}
+
+// newAuditApp returns an app with one arm holding license 7 with 3 digs,
+// 2 permits handed out and 1 of them closed
+func newAuditApp(t *testing.T, server *fakeLicenses) (*App, *bytes.Buffer) {
+	var cfg config.Config
+	buf := &bytes.Buffer{}
+	log := logger.New(buf, logger.Warn, logger.JSON)
+	metrics := mertics.New(true)
+	app := &App{
+		api:     newTestAPI(t, &cfg, metrics, server),
+		metrics: metrics,
+		log:     log,
+		config:  cfg,
+		arms: []*arm{{
+			name:     "a",
+			licenses: license.NewPool(1, 1, logger.Nop()),
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	pool := app.arms[0].licenses
+	h, err := pool.RequestAddContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Ok(7, 3); err != nil {
+		t.Fatal(err)
+	}
+	b, err := pool.GetBatch(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permit, _ := b.Next()
+	permit.Close()
+	b.Next()
+	return app, buf
+}
+
+func TestAuditLeasesAgainstServer(t *testing.T) {
+	server := &fakeLicenses{}
+	app, buf := newAuditApp(t, server)
+	counter := func(key string) float64 {
+		return app.metrics.Snapshot().Counters[key]
+	}
+
+	// the server counts the closed permit and may count the open one
+	for _, used := range []string{"1", "2"} {
+		server.set(`[{"id":7,"digAllowed":3,"digUsed":` + used + `}]`)
+		app.auditLeases(time.Now())
+		if n := counter("lease_mismatches"); n != 0 {
+			t.Fatalf("%v mismatches with %s digs used, log: %s", n, used, buf)
+		}
+	}
+
+	server.set(`[{"id":7,"digAllowed":3,"digUsed":3}]`)
+	app.auditLeases(time.Now())
+	if n := counter("lease_mismatches"); n != 1 {
+		t.Fatalf("%v mismatches with more digs used than handed out", n)
+	}
+
+	// an empty list is one failure, not a mismatch per lease
+	buf.Reset()
+	server.set(`[]`)
+	app.auditLeases(time.Now())
+	if n := counter("lease_mismatches"); n != 1 {
+		t.Fatalf("%v mismatches after an empty list", n)
+	}
+	if n := counter("empty_license_lists"); n != 1 {
+		t.Fatalf("%v empty lists counted", n)
+	}
+	if out := buf.String(); strings.Count(out, "\n") != 1 || !strings.Contains(out, "server listed no licenses") {
+		t.Fatalf("log after an empty list: %s", out)
+	}
+}
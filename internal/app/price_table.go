@@ -0,0 +1,80 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// controlPriceList returns the price list persisted between runs, the
+// experiment arm of an a/b test always starts from scratch.
+func (app *App) controlPriceList() (*license.PriceList, bool) {
+	if app.config.App.License.PriceTable.Path == "" {
+		return nil, false
+	}
+	p, ok := app.arms[0].priceList.(*license.PriceList)
+	return p, ok
+}
+
+// priceHalfLife is how fast saved prices lose confidence, they never do if
+// it is empty
+func (app *App) priceHalfLife() time.Duration {
+	if halfLife := app.config.App.License.PriceTable.HalfLife; halfLife != "" {
+		return halfLife.Parse()
+	}
+	return 0
+}
+
+// seedPriceTable loads the table saved by the previous run, only the price
+// list pricer can be persisted
+func (app *App) seedPriceTable() error {
+	cfg := app.config.App.License.PriceTable
+	if cfg.Path == "" {
+		return nil
+	}
+	p, ok := app.controlPriceList()
+	if !ok {
+		return fmt.Errorf("license.price_table needs the price_list pricer, not %q", app.config.App.License.Pricer)
+	}
+	t, err := license.LoadPriceTable(cfg.Path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			app.log.Warn("failed to load price table", logger.F("path", cfg.Path), logger.F("error", err))
+		}
+		return nil
+	}
+	halfLife := app.priceHalfLife()
+	fresh := t.Prune(halfLife, cfg.MinConfidence)
+	if len(fresh.Digs) == 0 {
+		app.log.Info("price table is too old", logger.F("saved_at", t.SavedAt), logger.F("prices", len(t.Digs)))
+		return nil
+	}
+	p.Seed(fresh, halfLife, cfg.Reverify)
+	app.log.Info("price table seeded",
+		logger.F("saved_at", t.SavedAt),
+		logger.F("prices", len(fresh.Digs)),
+		logger.F("too_old", len(t.Digs)-len(fresh.Digs)),
+	)
+	return nil
+}
+
+func (app *App) savePriceTable() {
+	p, ok := app.controlPriceList()
+	if !ok {
+		return
+	}
+	path := app.config.App.License.PriceTable.Path
+	t := p.Table()
+	if len(t.Digs) == 0 {
+		// don't overwrite a table with nothing
+		return
+	}
+	if err := t.Save(path); err != nil {
+		app.log.Error("failed to save price table", logger.F("path", path), logger.F("error", err))
+		return
+	}
+	app.log.Info("price table saved", logger.F("path", path), logger.F("prices", len(t.Digs)))
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/strategy"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+func newPriceTableApp(t *testing.T, cfg config.Config) *App {
+	arms, err := newArms(cfg, logger.Nop(), nil, strategy.Deps{Log: logger.Nop()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &App{config: cfg, log: logger.Nop(), arms: arms}
+}
+
+func TestSeedPriceTableWithoutHalfLife(t *testing.T) {
+	var cfg config.Config
+	cfg.App.License.PriceList.Experiments = 4
+	cfg.App.License.PriceTable.Path = filepath.Join(t.TempDir(), "prices.json")
+	table := license.PriceTable{
+		SavedAt:  time.Now().Add(-24 * 365 * time.Hour),
+		Digs:     map[int64]int64{2: 7},
+		Measured: map[int64]time.Time{},
+	}
+	if err := table.Save(cfg.App.License.PriceTable.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newPriceTableApp(t, cfg)
+	if err := app.seedPriceTable(); err != nil {
+		t.Fatal(err)
+	}
+	// without a half-life even a year old price is trusted
+	if digs := app.arms[0].priceList.Map()[2]; digs != 7 {
+		t.Fatalf("seeded digs = %d, want 7", digs)
+	}
+}
+
+func TestPriceTableNeedsPriceList(t *testing.T) {
+	var cfg config.Config
+	cfg.App.License.Pricer = "thompson"
+	cfg.App.License.Thompson.MaxPrice = 4
+	cfg.App.License.PriceTable.Path = filepath.Join(t.TempDir(), "prices.json")
+	if err := newPriceTableApp(t, cfg).seedPriceTable(); err == nil {
+		t.Fatal("a price table was accepted for the thompson pricer")
+	}
+
+	cfg.App.License.PriceTable.Path = ""
+	if err := newPriceTableApp(t, cfg).seedPriceTable(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type worker struct {
+	id      int
+	pool    *pool
+	leaving bool
+	// done once the pool shrinks or stops, workers wait for their next job
+	// with it so idle ones can leave
+	idle context.Context
+}
+
+// next blocks while the pool is paused and reports whether the worker should
+// take another job, workers call it between jobs and return once it is false.
+// A worker whose wait for a job is cut short by w.idle calls it again.
+func (w *worker) next() bool {
+	p := w.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.stopped || p.running-p.leaving > p.size {
+			p.leaving++
+			w.leaving = true
+			return false
+		}
+		if !p.paused {
+			w.idle = p.idle
+			return true
+		}
+		p.cond.Wait()
+	}
+}
+
+// took counts a job once the worker has it
+func (w *worker) took() {
+	p := w.pool
+	p.mu.Lock()
+	p.jobs++
+	p.mu.Unlock()
+}
+
+// pool runs the goroutines of one pipeline stage. It can be paused and
+// resized at runtime, extra workers leave after finishing their current job.
+type pool struct {
+	name string
+	work func(w *worker)
+
+	size    int
+	running int
+	leaving int
+	nextID  int
+	paused  bool
+	stopped bool
+	// jobs taken so far, used to measure throughput
+	jobs int64
+	// cancelled to wake up the idle workers whenever some have to leave
+	idle context.Context
+	wake context.CancelFunc
+	mu   sync.Mutex
+	cond *sync.Cond
+}
+
+func (p *pool) run(w *worker, work func(w *worker)) {
+	defer func() {
+		p.mu.Lock()
+		p.running--
+		if w.leaving {
+			p.leaving--
+		} else {
+			// the worker finished on its own, don't replace it
+			p.size--
+		}
+		p.mu.Unlock()
+	}()
+	work(w)
+}
+
+// spawnLocked starts a worker, p.mu must be held
+func (p *pool) spawnLocked(work func(w *worker)) {
+	w := &worker{id: p.nextID, pool: p}
+	p.nextID++
+	p.running++
+	go p.run(w, work)
+}
+
+// spawn grows the pool by n workers running a custom job
+func (p *pool) spawn(n int, work func(w *worker)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	p.size += n
+	for i := 0; i < n; i++ {
+		p.spawnLocked(work)
+	}
+}
+
+// wakeLocked wakes up the workers waiting for a job so the ones that have
+// to leave notice it, p.mu must be held
+func (p *pool) wakeLocked() {
+	p.wake()
+	p.idle, p.wake = context.WithCancel(context.Background())
+	// paused workers have to wake up to leave
+	p.cond.Broadcast()
+}
+
+// resize starts or stops workers until there are n of them
+func (p *pool) resize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	shrink := n < p.size
+	p.size = n
+	for p.running-p.leaving < p.size {
+		p.spawnLocked(p.work)
+	}
+	if shrink {
+		p.wakeLocked()
+	}
+}
+
+// stop makes every worker leave once it is done with its current job
+func (p *pool) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	p.wakeLocked()
+}
+
+func (p *pool) pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+}
+
+func (p *pool) resume() {
+	p.mu.Lock()
+	p.paused = false
+	p.cond.Broadcast()
+	p.mu.Unlock()
+}
+
+type poolState struct {
+	Size    int   `json:"size"`
+	Running int   `json:"running"`
+	Leaving int   `json:"leaving"`
+	Paused  bool  `json:"paused"`
+	Stopped bool  `json:"stopped"`
+	Jobs    int64 `json:"jobs"`
+}
+
+func (p *pool) state() poolState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return poolState{
+		Size:    p.size,
+		Running: p.running,
+		Leaving: p.leaving,
+		Paused:  p.paused,
+		Stopped: p.stopped,
+		Jobs:    p.jobs,
+	}
+}
+
+func newPool(name string, work func(w *worker)) *pool {
+	p := &pool{
+		name: name,
+		work: work,
+	}
+	p.idle, p.wake = context.WithCancel(context.Background())
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+type workers struct {
+	cashers, explorers, diggers, licenseIssuers, preExplorers *pool
+	byName                                                    map[string]*pool
+}
+
+func newWorkers(app *App) *workers {
+	w := &workers{
+		cashers:        newPool("cashers", app.runCasher),
+		explorers:      newPool("explorers", app.runExplorer),
+		diggers:        newPool("diggers", app.runDigger),
+		licenseIssuers: newPool("license_issuers", app.runLicenseIssuer),
+		preExplorers: newPool("pre_explorers", func(w *worker) {
+			deadline := time.Now().Add(app.config.App.PreExplorationTimeout.Parse())
+			app.preExplore(w, deadline, app.config.App.MaxBlocksPerPreExploreWorker)
+		}),
+	}
+	w.byName = map[string]*pool{}
+	for _, p := range []*pool{w.cashers, w.explorers, w.diggers, w.licenseIssuers, w.preExplorers} {
+		w.byName[p.name] = p
+	}
+	return w
+}
+
+// stop makes the workers of every pool leave
+func (w *workers) stop() {
+	for _, p := range w.byName {
+		p.stop()
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newChanPool(jobs chan int, done chan int) *pool {
+	return newPool("test", func(w *worker) {
+		for w.next() {
+			select {
+			case j := <-jobs:
+				w.took()
+				done <- j
+			case <-w.idle.Done():
+			}
+		}
+	})
+}
+
+func TestPoolShrinksIdleWorkers(t *testing.T) {
+	jobs, done := make(chan int), make(chan int, 10)
+	p := newChanPool(jobs, done)
+	p.resize(4)
+	waitFor(t, "4 workers", func() bool { return p.state().Running == 4 })
+
+	p.resize(1)
+	waitFor(t, "idle workers to leave", func() bool { return p.state().Running == 1 })
+	if s := p.state(); s.Size != 1 || s.Leaving != 0 {
+		t.Fatalf("state after shrinking = %+v", s)
+	}
+	if s := p.state(); s.Jobs != 0 {
+		t.Fatalf("jobs = %d before any job was taken", s.Jobs)
+	}
+
+	jobs <- 1
+	<-done
+	if s := p.state(); s.Jobs != 1 {
+		t.Fatalf("jobs = %d, want 1", s.Jobs)
+	}
+
+	p.stop()
+	waitFor(t, "the pool to stop", func() bool { return p.state().Running == 0 })
+	p.resize(3)
+	p.spawn(1, p.work)
+	if s := p.state(); s.Running != 0 || !s.Stopped {
+		t.Fatalf("a stopped pool started workers: %+v", s)
+	}
+}
+
+func TestPoolPausedWorkersLeave(t *testing.T) {
+	jobs, done := make(chan int), make(chan int, 10)
+	p := newChanPool(jobs, done)
+	p.resize(2)
+	waitFor(t, "2 workers", func() bool { return p.state().Running == 2 })
+	p.pause()
+	p.resize(0)
+	waitFor(t, "paused workers to leave", func() bool { return p.state().Running == 0 })
+}
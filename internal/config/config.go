@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -20,11 +21,152 @@ type PollerConfig struct {
 	MaxIters int      `json:"max_iters"`
 }
 
+// RateLimitConfig configures a token bucket, a non-positive rate disables it
+type RateLimitConfig struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+type LoggerConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Interval Duration `json:"interval"`
+	// debug, info, warn or error
+	Level string `json:"level"`
+	// console or json
+	Format string `json:"format"`
+	// file to append to, stderr if empty
+	Output string `json:"output"`
+}
+
+type JournalConfig struct {
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir"`
+	// events queued for writing, extra events are dropped
+	BufferSize int `json:"buffer_size"`
+	// bytes per file before rotation, 0 disables rotation
+	MaxFileSize   int64    `json:"max_file_size"`
+	FlushInterval Duration `json:"flush_interval"`
+}
+
+// AutoscalerConfig moves workers between explorers, diggers and cashers
+type AutoscalerConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Interval Duration `json:"interval"`
+	// workers shared by the stages, the sum of their configured sizes if 0
+	Budget int `json:"budget"`
+	// workers a stage keeps however short its queue is
+	MinWorkers int `json:"min_workers"`
+	// workers moved per interval at most
+	Step int `json:"step"`
+}
+
+// GameConfig sets the length of the game and when each end-game phase
+// starts, phases are given as the time left and are disabled if empty.
+type GameConfig struct {
+	// time from the start of the bot to the end of the game, no deadline if empty
+	Duration               Duration `json:"duration"`
+	StopPreExploreBefore   Duration `json:"stop_pre_explore_before"`
+	StopPaidLicensesBefore Duration `json:"stop_paid_licenses_before"`
+	CashOutBefore          Duration `json:"cash_out_before"`
+	// cashers to run while cashing out
+	CashOutCashers int `json:"cash_out_cashers"`
+}
+
+// ABConfig runs a second strategy or parameter set next to the configured
+// one, the world and the license issuers are split between them.
+type ABConfig struct {
+	Enabled bool `json:"enabled"`
+	// strategy of the experiment arm, the configured one if empty
+	Strategy string `json:"strategy"`
+	// fields of the "app" section to change for the experiment arm
+	Overrides      json.RawMessage `json:"overrides"`
+	SampleInterval Duration        `json:"sample_interval"`
+}
+
+// PIDConfig tunes the pid price controller, the controlled value is the
+// slope of coins per second over the license price.
+type PIDConfig struct {
+	Kp float64 `json:"kp"`
+	Ki float64 `json:"ki"`
+	Kd float64 `json:"kd"`
+	// bound of the integral term against windup
+	MaxIntegral float64 `json:"max_integral"`
+
+	StartPrice int64 `json:"start_price"`
+	MinPrice   int64 `json:"min_price"`
+	MaxPrice   int64 `json:"max_price"`
+	MinStep    int64 `json:"min_step"`
+	MaxStep    int64 `json:"max_step"`
+	// rate samples averaged before each price change
+	Window int `json:"window"`
+}
+
+type PriceControllerConfig struct {
+	// legacy or pid
+	Type     string    `json:"type"`
+	Interval Duration  `json:"interval"`
+	PID      PIDConfig `json:"pid"`
+}
+
+type EconomicsConfig struct {
+	Enabled bool `json:"enabled"`
+	// digs and cashes seen before licenses are judged
+	MinSamples int `json:"min_samples"`
+	// expected return per spent coin a paid license needs
+	Margin float64 `json:"margin"`
+}
+
+type DigModelConfig struct {
+	Enabled bool `json:"enabled"`
+	// digs seen before cells are stopped early
+	MinSamples int `json:"min_samples"`
+	// pseudo treasures of the prior that spreads a cell's treasures evenly
+	// over the depths left
+	Prior float64 `json:"prior"`
+	// pseudo treasures pulling the coins per treasure of a depth to the average
+	CoinsPrior float64 `json:"coins_prior"`
+	// share of the average coin rate the time of a dig is charged at
+	TimeWeight float64 `json:"time_weight"`
+	// expected coins a dig needs per coin it costs
+	Margin float64 `json:"margin"`
+}
+
+type DensityConfig struct {
+	// experimental, Explored holds the map lock over (2*radius+1)^2 blocks,
+	// see BenchmarkDensityMapContention
+	Enabled bool `json:"enabled"`
+	// blocks around an explored block whose score it changes, at most 90
+	Radius int `json:"radius"`
+	// pseudo blocks pulling a neighbourhood to the mean of all blocks
+	Prior float64 `json:"prior"`
+}
+
+type BudgetConfig struct {
+	Enabled bool `json:"enabled"`
+	// coins never spent, the larger of the two
+	Reserve      int64   `json:"reserve"`
+	ReserveShare float64 `json:"reserve_share"`
+	// coins spent on licenses per minute at most, unlimited if 0
+	SpendPerMinute int64 `json:"spend_per_minute"`
+	// share of the coins spent over the game and of the per minute cap
+	// price experiments may use
+	ExperimentShare float64 `json:"experiment_share"`
+}
+
 type Config struct {
-	Logger struct {
-		Enabled  bool     `json:"enabled"`
-		Interval Duration `json:"interval"`
-	} `json:"logger"`
+	Logger  LoggerConfig  `json:"logger"`
+	Journal JournalConfig `json:"journal"`
+
+	Ledger struct {
+		Enabled bool `json:"enabled"`
+		// file the ledger is exported to on shutdown, not exported if empty
+		Export string `json:"export"`
+	} `json:"ledger"`
+
+	Admin struct {
+		Enabled bool   `json:"enabled"`
+		Address string `json:"address"`
+	} `json:"admin"`
 
 	Api struct {
 		DigPoller          PollerConfig `json:"dig_poller"`
@@ -34,6 +176,14 @@ type Config struct {
 		BalancePoller      PollerConfig `json:"balance_poller"`
 		IssueLicensePoller PollerConfig `json:"issue_license_poller"`
 		ListLicensesPoller PollerConfig `json:"list_licenses_poller"`
+
+		RateLimits struct {
+			Dig         RateLimitConfig `json:"dig"`
+			HealthCheck RateLimitConfig `json:"health_check"`
+			Cash        RateLimitConfig `json:"cash"`
+			Explore     RateLimitConfig `json:"explore"`
+			Licenses    RateLimitConfig `json:"licenses"`
+		} `json:"rate_limits"`
 	} `json:"api"`
 
 	App struct {
@@ -46,14 +196,61 @@ type Config struct {
 		PreExplorationTimeout        Duration `json:"pre_exploration_timeout"`
 
 		MinTreasuresPerBlock 		 int `json:"min_treasures_per_block"`
+		// name of a registered strategy, the default one if empty
+		Strategy string `json:"strategy"`
+
+		Autoscaler AutoscalerConfig `json:"autoscaler"`
+		Game       GameConfig       `json:"game"`
+		AB         ABConfig         `json:"ab"`
+
+		PriceController PriceControllerConfig `json:"price_controller"`
+		Economics       EconomicsConfig       `json:"economics"`
+		DigModel        DigModelConfig        `json:"dig_model"`
+		Density         DensityConfig         `json:"density"`
+		Budget          BudgetConfig          `json:"budget"`
 
 		License struct {
+			PriceTable struct {
+				// file the price list is seeded from and saved to, disabled if
+				// empty, only the price_list pricer is persisted
+				Path string `json:"path"`
+				// saved prices never lose confidence if empty
+				HalfLife Duration `json:"half_life"`
+				// less confident prices are seeded but measured again
+				Reverify float64 `json:"reverify"`
+				// less confident prices are ignored
+				MinConfidence float64 `json:"min_confidence"`
+			} `json:"price_table"`
+			Free struct {
+				// free and paid licenses issued before the price list picks
+				// free ones when they bring more digs per second, 0 disables it
+				MinSamples int `json:"min_samples"`
+			} `json:"free"`
+			// price_list or thompson
+			Pricer string `json:"pricer"`
+			Thompson struct {
+				// highest bucket, buckets are the powers of two up to it
+				MaxPrice   int     `json:"max_price"`
+				PriorDigs  float64 `json:"prior_digs"`
+				PriorCoins float64 `json:"prior_coins"`
+			} `json:"thompson"`
 			PriceList struct {
 				Experiments int `json:"experiments"`
 				K int `json:"k"`
 				G float64 `json:"g"`
 			} `json:"price_list"`
 			MaxAmount int `json:"max_amount"`
+			// shards of the license pool, the single lock manager if 1 or less
+			Shards int `json:"shards"`
+			// diggers waiting longer for a license log it and wait again,
+			// no timeout if empty
+			GetTimeout Duration `json:"get_timeout"`
+			Audit struct {
+				// how often handles and the digs used are checked, disabled if empty
+				Interval Duration `json:"interval"`
+				// permits held longer are reported as leaks, never if empty
+				MaxAge Duration `json:"max_age"`
+			} `json:"audit"`
 		} `json:"license"`
 
 		World struct {
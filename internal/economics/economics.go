@@ -0,0 +1,194 @@
+package economics
+
+import (
+	"sync"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+type depthStats struct {
+	digs, treasures int64
+}
+
+type digsStats struct {
+	licenses, digs int64
+}
+
+// purchase is a paid license and what it has returned so far
+type purchase struct {
+	price     int64
+	projected float64
+	realised  int64
+}
+
+// Estimator projects the coins a license returns from the coins per
+// treasure, the treasures per dig at each depth, the depth mix and the digs
+// seen at each price, and follows the realised return of every license.
+type Estimator struct {
+	mu     sync.Mutex
+	config config.EconomicsConfig
+
+	depths          map[int64]*depthStats
+	cashed, coins   int64
+	digsPerPrice    map[int64]*digsStats
+	purchases       map[int64]*purchase
+	spent, realised int64
+	projected       float64
+}
+
+// New returns nil if the estimator is disabled, a nil estimator is a no-op
+// that allows every purchase.
+func New(cfg config.EconomicsConfig) *Estimator {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &Estimator{
+		config:       cfg,
+		depths:       map[int64]*depthStats{},
+		digsPerPrice: map[int64]*digsStats{},
+		purchases:    map[int64]*purchase{},
+	}
+}
+
+// Dug records a successful or empty dig
+func (e *Estimator) Dug(depth int64, treasures int) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.depths[depth]
+	if !ok {
+		s = &depthStats{}
+		e.depths[depth] = s
+	}
+	s.digs++
+	s.treasures += int64(treasures)
+}
+
+// Cashed records the coins of a treasure dug with the license
+func (e *Estimator) Cashed(licenseID int64, coins int) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cashed++
+	e.coins += int64(coins)
+	if p, ok := e.purchases[licenseID]; ok {
+		p.realised += int64(coins)
+		e.realised += int64(coins)
+	}
+}
+
+// LicenseIssued records the digs bought at the price and projects their return
+func (e *Estimator) LicenseIssued(licenseID, price, digs int64) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.digsPerPrice[price]
+	if !ok {
+		s = &digsStats{}
+		e.digsPerPrice[price] = s
+	}
+	s.licenses++
+	s.digs += digs
+	if price <= 0 {
+		return
+	}
+	projected := float64(digs) * e.coinsPerDig()
+	e.purchases[licenseID] = &purchase{price: price, projected: projected}
+	e.spent += price
+	e.projected += projected
+}
+
+// coinsPerDig weights the treasures per dig of every depth by its share of
+// the digs, e.mu must be held. It is 0 until enough digs and cashes are seen.
+func (e *Estimator) coinsPerDig() float64 {
+	if e.cashed < int64(e.config.MinSamples) {
+		return 0
+	}
+	var digs int64
+	for _, s := range e.depths {
+		digs += s.digs
+	}
+	if digs < int64(e.config.MinSamples) {
+		return 0
+	}
+	var treasuresPerDig float64
+	for _, s := range e.depths {
+		share := float64(s.digs) / float64(digs)
+		treasuresPerDig += share * float64(s.treasures) / float64(s.digs)
+	}
+	return treasuresPerDig * float64(e.coins) / float64(e.cashed)
+}
+
+// expectedDigs returns the average digs at the price, or at the closest
+// lower paid price if the price wasn't seen yet, e.mu must be held. ok is
+// false if there is no such price, free licenses say nothing about paid ones.
+func (e *Estimator) expectedDigs(price int64) (float64, bool) {
+	if s, ok := e.digsPerPrice[price]; ok {
+		return float64(s.digs) / float64(s.licenses), true
+	}
+	// more coins never buy fewer digs, so the closest lower price is a safe guess
+	closest := int64(0)
+	for p := range e.digsPerPrice {
+		if p > 0 && p < price && p > closest {
+			closest = p
+		}
+	}
+	if closest == 0 {
+		return 0, false
+	}
+	s := e.digsPerPrice[closest]
+	return float64(s.digs) / float64(s.licenses), true
+}
+
+// Worth reports whether a license at the price is expected to return at
+// least Margin times its price. It is true while there is too little data.
+func (e *Estimator) Worth(price int64) bool {
+	if e == nil || price <= 0 {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	perDig := e.coinsPerDig()
+	digs, ok := e.expectedDigs(price)
+	if perDig == 0 || !ok {
+		return true
+	}
+	return digs*perDig >= float64(price)*e.config.Margin
+}
+
+type Report struct {
+	CoinsPerDig float64 `json:"coins_per_dig"`
+	Licenses    int     `json:"licenses"`
+	Spent       int64   `json:"spent"`
+	Projected   float64 `json:"projected"`
+	Realised    int64   `json:"realised"`
+	// returned coins per spent coin
+	ProjectedROI float64 `json:"projected_roi"`
+	RealisedROI  float64 `json:"realised_roi"`
+}
+
+func (e *Estimator) Report() Report {
+	if e == nil {
+		return Report{}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	r := Report{
+		CoinsPerDig: e.coinsPerDig(),
+		Licenses:    len(e.purchases),
+		Spent:       e.spent,
+		Projected:   e.projected,
+		Realised:    e.realised,
+	}
+	if e.spent > 0 {
+		r.ProjectedROI = e.projected / float64(e.spent)
+		r.RealisedROI = float64(e.realised) / float64(e.spent)
+	}
+	return r
+}
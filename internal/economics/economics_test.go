@@ -0,0 +1,96 @@
+package economics
+
+import (
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+// newEstimator returns an estimator that knows a dig is worth 2 coins
+func newEstimator() *Estimator {
+	e := New(config.EconomicsConfig{Enabled: true, MinSamples: 1, Margin: 1})
+	for i := 0; i < 10; i++ {
+		e.Dug(1, 1)
+	}
+	e.Cashed(0, 2)
+	return e
+}
+
+func TestWorth(t *testing.T) {
+	e := newEstimator()
+	if !e.Worth(5) {
+		t.Fatal("a license is not worth buying without any data on prices")
+	}
+
+	// free licenses say nothing about paid ones
+	e.LicenseIssued(1, 0, 3)
+	if !e.Worth(5) {
+		t.Fatal("a paid license was judged by the free ones")
+	}
+
+	// 4 digs for 10 coins return 8
+	e.LicenseIssued(2, 10, 4)
+	cases := []struct {
+		price int64
+		worth bool
+	}{
+		{price: 10, worth: false},
+		// judged by the digs at 10 coins
+		{price: 20, worth: false},
+		// no lower paid price is known, a higher one is no guess
+		{price: 5, worth: true},
+		{price: 0, worth: true},
+	}
+	for _, c := range cases {
+		if got := e.Worth(c.price); got != c.worth {
+			t.Errorf("Worth(%d) = %v, want %v", c.price, got, c.worth)
+		}
+	}
+
+	// 3 digs for 2 coins return 6
+	e.LicenseIssued(3, 2, 3)
+	if !e.Worth(2) || !e.Worth(5) {
+		t.Fatal("prices up to the return of the closest lower price are not worth it")
+	}
+	if e.Worth(7) {
+		t.Fatal("7 coins for the digs of 2 coins are worth it")
+	}
+}
+
+func TestWorthWithTooFewSamples(t *testing.T) {
+	e := New(config.EconomicsConfig{Enabled: true, MinSamples: 100, Margin: 1})
+	e.Dug(1, 0)
+	e.LicenseIssued(1, 10, 1)
+	if !e.Worth(10) {
+		t.Fatal("a license is judged before MinSamples")
+	}
+}
+
+func TestDisabledEstimator(t *testing.T) {
+	e := New(config.EconomicsConfig{})
+	if e != nil {
+		t.Fatal("a disabled estimator is not nil")
+	}
+	e.Dug(1, 1)
+	e.Cashed(1, 1)
+	e.LicenseIssued(1, 1, 1)
+	if !e.Worth(100) {
+		t.Fatal("a nil estimator refused a purchase")
+	}
+	if r := e.Report(); r != (Report{}) {
+		t.Fatalf("report of a nil estimator = %+v", r)
+	}
+}
+
+func TestReport(t *testing.T) {
+	e := newEstimator()
+	e.LicenseIssued(1, 10, 5)
+	e.Cashed(1, 4)
+	r := e.Report()
+	if r.Licenses != 1 || r.Spent != 10 || r.Realised != 4 {
+		t.Fatalf("report = %+v", r)
+	}
+	if r.RealisedROI != 0.4 {
+		t.Fatalf("realised roi = %v, want 0.4", r.RealisedROI)
+	}
+}
@@ -0,0 +1,284 @@
+package area
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/condwait"
+)
+
+const (
+	// relative change of the mean after which the scores are computed again
+	rescoreDrift = 0.05
+	// keeps the explored blocks around a block within an int16
+	maxRadius = 90
+)
+
+// frontier is a heap of the unexplored blocks with explored neighbours,
+// richest neighbourhood first
+type frontier struct {
+	items []int32
+	// position of every block in items, -1 if it isn't in the heap
+	pos   []int32
+	score []float32
+}
+
+func (f *frontier) Len() int {
+	return len(f.items)
+}
+
+func (f *frontier) Less(i, j int) bool {
+	return f.score[f.items[i]] > f.score[f.items[j]]
+}
+
+func (f *frontier) Swap(i, j int) {
+	f.items[i], f.items[j] = f.items[j], f.items[i]
+	f.pos[f.items[i]] = int32(i)
+	f.pos[f.items[j]] = int32(j)
+}
+
+func (f *frontier) Push(x interface{}) {
+	b := x.(int32)
+	f.pos[b] = int32(len(f.items))
+	f.items = append(f.items, b)
+}
+
+func (f *frontier) Pop() interface{} {
+	n := len(f.items) - 1
+	b := f.items[n]
+	f.items = f.items[:n]
+	f.pos[b] = -1
+	return b
+}
+
+type DensityStats struct {
+	Unexplored int `json:"unexplored"`
+	// unexplored blocks with explored blocks around them
+	Near     int `json:"near"`
+	Explored int `json:"explored"`
+	// treasures per explored block
+	Mean float64 `json:"mean"`
+	// score of the next block
+	Best float64 `json:"best"`
+}
+
+// block states
+const (
+	// no explored block around it, scores the mean
+	far uint8 = iota
+	// in the frontier heap
+	near
+	// handed out
+	popped
+)
+
+// DensityMap hands out the unexplored blocks of the world, the ones with
+// the richest explored neighbourhood first. The score of a block is the
+// mean treasures of the explored blocks within the radius, pulled to the
+// mean of all explored blocks by Prior pseudo blocks. Blocks far from
+// anything explored score the mean and are handed out in a random order,
+// so disabled it is a shuffled list of the blocks.
+type DensityMap struct {
+	mu sync.Mutex
+	// never signalled since no blocks are added, only woken by condwait
+	cond *sync.Cond
+
+	config        config.DensityConfig
+	sx, sy        int64
+	width, height int64
+	nx, ny        int64
+
+	state    []uint8
+	frontier frontier
+	// the far blocks in a random order, the ones before next are handed
+	// out or near
+	far  []int32
+	next int
+	// blocks not handed out yet
+	left int
+	// treasures and explored blocks within the radius of every block
+	sum []int32
+	n   []int16
+
+	explored, treasures int64
+	// the mean the scores were last computed with
+	scoredMean float64
+}
+
+func NewDensityMap(cfg config.Config) *DensityMap {
+	world, block := cfg.App.World, cfg.App.Block
+	d := &DensityMap{
+		config: cfg.App.Density,
+		sx:     int64(world.SX),
+		sy:     int64(world.SY),
+		width:  int64(block.Width),
+		height: int64(block.Height),
+	}
+	d.cond = sync.NewCond(&d.mu)
+	if d.config.Radius > maxRadius {
+		d.config.Radius = maxRadius
+	}
+	// the same blocks as GenerateAreas
+	d.nx = (int64(world.Width) - d.sx + d.width - 1) / d.width
+	d.ny = (int64(world.Height) - d.sy + d.height - 1) / d.height
+	if d.nx < 0 || d.ny < 0 {
+		d.nx, d.ny = 0, 0
+	}
+	blocks := int(d.nx * d.ny)
+	d.left = blocks
+	d.state = make([]uint8, blocks)
+	d.far = make([]int32, blocks)
+	for i, b := range rand.Perm(blocks) {
+		d.far[i] = int32(b)
+	}
+	if d.config.Enabled {
+		d.sum = make([]int32, blocks)
+		d.n = make([]int16, blocks)
+		d.frontier = frontier{
+			pos:   make([]int32, blocks),
+			score: make([]float32, blocks),
+		}
+	}
+	return d
+}
+
+func (d *DensityMap) area(b int32) Area {
+	ix, iy := int64(b)/d.ny, int64(b)%d.ny
+	return Area{
+		X: d.sx + ix*d.width,
+		Y: d.sy + iy*d.height,
+		W: d.width,
+		H: d.height,
+	}
+}
+
+// block returns the index of the block the area starts in
+func (d *DensityMap) block(a Area) (int32, bool) {
+	ix, iy := (a.X-d.sx)/d.width, (a.Y-d.sy)/d.height
+	if a.X < d.sx || a.Y < d.sy || ix >= d.nx || iy >= d.ny {
+		return 0, false
+	}
+	return int32(ix*d.ny + iy), true
+}
+
+// mean returns the treasures per explored block, d.mu must be held
+func (d *DensityMap) mean() float64 {
+	if d.explored == 0 {
+		return 0
+	}
+	return float64(d.treasures) / float64(d.explored)
+}
+
+// score computes the score of a near block, d.mu must be held
+func (d *DensityMap) score(b int32, mean float64) float32 {
+	n := float64(d.n[b]) + d.config.Prior
+	return float32((float64(d.sum[b]) + d.config.Prior*mean) / n)
+}
+
+// nextFar skips the far list to the next block that is still far, d.mu
+// must be held
+func (d *DensityMap) nextFar() (int32, bool) {
+	for d.next < len(d.far) && d.state[d.far[d.next]] != far {
+		d.next++
+	}
+	if d.next == len(d.far) {
+		return 0, false
+	}
+	return d.far[d.next], true
+}
+
+// PopContext returns the best unexplored block, once all of them are handed
+// out it blocks until ctx is done
+func (d *DensityMap) PopContext(ctx context.Context) (Area, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := condwait.Wait(ctx, d.cond, func() bool { return d.left > 0 }); err != nil {
+		return Area{}, err
+	}
+	b, ok := d.nextFar()
+	if d.frontier.Len() > 0 && (!ok || float64(d.frontier.score[d.frontier.items[0]]) >= d.mean()) {
+		b = heap.Pop(&d.frontier).(int32)
+	}
+	d.state[b] = popped
+	d.left--
+	return d.area(b), nil
+}
+
+// Len returns the unexplored blocks not handed out yet
+func (d *DensityMap) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.left
+}
+
+// Explored records the treasures of an explored block and scores the
+// unexplored blocks around it again
+func (d *DensityMap) Explored(a Area) {
+	if !d.config.Enabled {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b, ok := d.block(a)
+	if !ok {
+		return
+	}
+	d.explored++
+	d.treasures += a.Treasures
+	mean := d.mean()
+
+	r := int64(d.config.Radius)
+	bx, by := int64(b)/d.ny, int64(b)%d.ny
+	for x := bx - r; x <= bx+r; x++ {
+		if x < 0 || x >= d.nx {
+			continue
+		}
+		for y := by - r; y <= by+r; y++ {
+			if y < 0 || y >= d.ny || x == bx && y == by {
+				continue
+			}
+			c := int32(x*d.ny + y)
+			d.sum[c] += int32(a.Treasures)
+			d.n[c]++
+			switch d.state[c] {
+			case far:
+				d.state[c] = near
+				d.frontier.score[c] = d.score(c, mean)
+				heap.Push(&d.frontier, c)
+			case near:
+				d.frontier.score[c] = d.score(c, mean)
+				heap.Fix(&d.frontier, int(d.frontier.pos[c]))
+			}
+		}
+	}
+
+	if math.Abs(mean-d.scoredMean) > rescoreDrift*d.scoredMean {
+		d.scoredMean = mean
+		for _, c := range d.frontier.items {
+			d.frontier.score[c] = d.score(c, mean)
+		}
+		heap.Init(&d.frontier)
+	}
+}
+
+func (d *DensityMap) Stats() DensityStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := DensityStats{
+		Unexplored: d.left,
+		Near:       d.frontier.Len(),
+		Explored:   int(d.explored),
+		Mean:       d.mean(),
+		Best:       d.mean(),
+	}
+	if d.frontier.Len() > 0 {
+		if best := float64(d.frontier.score[d.frontier.items[0]]); best > s.Best {
+			s.Best = best
+		}
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package area
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func newDensityMap(enabled bool, size, radius int) *DensityMap {
+	var cfg config.Config
+	cfg.App.World.Width = size
+	cfg.App.World.Height = size
+	cfg.App.Block.Width = 1
+	cfg.App.Block.Height = 1
+	cfg.App.Density.Enabled = enabled
+	cfg.App.Density.Radius = radius
+	cfg.App.Density.Prior = 1
+	return NewDensityMap(cfg)
+}
+
+func TestDensityMapDisabledHandsOutEveryBlock(t *testing.T) {
+	d := newDensityMap(false, 4, 1)
+	seen := map[Area]bool{}
+	for i := 0; i < 16; i++ {
+		a, err := d.PopContext(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[a] {
+			t.Fatalf("%+v handed out twice", a)
+		}
+		seen[a] = true
+		d.Explored(Area{X: a.X, Y: a.Y, W: 1, H: 1, Treasures: 1})
+	}
+	if d.Len() != 0 {
+		t.Fatalf("len = %d after handing out every block", d.Len())
+	}
+	if s := d.Stats(); s.Explored != 0 || s.Near != 0 {
+		t.Fatalf("disabled map tracks explored blocks: %+v", s)
+	}
+}
+
+func TestDensityMapDrainedWaitsForContext(t *testing.T) {
+	d := newDensityMap(true, 1, 1)
+	if _, err := d.PopContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := d.PopContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("pop from a drained map = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDensityMapRichNeighbourhoodFirst(t *testing.T) {
+	d := newDensityMap(true, 10, 1)
+	ctx := context.Background()
+	// explore two blocks, only the one at (5, 5) has treasures
+	for _, a := range []Area{{X: 5, Y: 5, Treasures: 10}, {X: 0, Y: 0}} {
+		for {
+			got, err := d.PopContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.X == a.X && got.Y == a.Y {
+				break
+			}
+		}
+	}
+	d.Explored(Area{X: 5, Y: 5, W: 1, H: 1, Treasures: 10})
+	d.Explored(Area{X: 0, Y: 0, W: 1, H: 1})
+
+	s := d.Stats()
+	if s.Explored != 2 || s.Mean != 5 || s.Best <= s.Mean {
+		t.Fatalf("stats = %+v", s)
+	}
+	a, err := d.PopContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.X < 4 || a.X > 6 || a.Y < 4 || a.Y > 6 {
+		t.Fatalf("popped %+v, want a neighbour of the rich block", a)
+	}
+}
+
+func TestDensityMapIgnoresAreasOutsideTheWorld(t *testing.T) {
+	d := newDensityMap(true, 4, 1)
+	d.Explored(Area{X: 10, Y: 10, W: 1, H: 1, Treasures: 3})
+	if s := d.Stats(); s.Explored != 0 {
+		t.Fatalf("explored = %d after an area outside the world", s.Explored)
+	}
+}
+
+// BenchmarkDensityMapContention pops blocks while others are explored, to
+// measure how long Explored holds the lock with the largest radius
+func BenchmarkDensityMapContention(b *testing.B) {
+	d := newDensityMap(true, 1000, maxRadius)
+	ctx := context.Background()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			a, err := d.PopContext(ctx)
+			if err != nil {
+				b.Fatal(err)
+			}
+			a.Treasures = 1
+			d.Explored(a)
+		}
+	})
+}
@@ -2,7 +2,10 @@ package area
 
 import (
 	"container/heap"
+	"context"
 	"sync"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/condwait"
 )
 
 type sortableArea []Area
@@ -86,6 +89,22 @@ func (q *Queue) Pop() Area {
 	return area
 }
 
+// PopContext is Pop that gives up once ctx is done
+func (q *Queue) PopContext(ctx context.Context) (Area, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := condwait.Wait(ctx, q.popCond, func() bool { return len(q.sortedAreas) > 0 }); err != nil {
+		return Area{}, err
+	}
+	srt := q.sortable()
+	prevLen := srt.Len()
+	area := heap.Pop(srt).(Area)
+	if prevLen >= q.blockingBufferSize && len(q.sortedAreas) < q.blockingBufferSize {
+		q.pushCond.Signal()
+	}
+	return area, nil
+}
+
 func (q *Queue) Size() int {
 	q.mu.Lock()
 	l := len(q.sortedAreas)
@@ -93,6 +112,11 @@ func (q *Queue) Size() int {
 	return l
 }
 
+// BufferSize returns the size above which Push blocks
+func (q *Queue) BufferSize() int {
+	return q.blockingBufferSize
+}
+
 func NewQueue(bsize int) *Queue {
 	q := &Queue{
 		sortedAreas:        nil,
@@ -0,0 +1,210 @@
+package coin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	CreditEntry = "credit"
+	DebitEntry  = "debit"
+)
+
+// Entry is one coin coming in from a treasure or going out to a license
+type Entry struct {
+	Type string    `json:"type"`
+	Coin uint32    `json:"coin"`
+	Time time.Time `json:"time"`
+
+	// credits
+	Treasure string `json:"treasure,omitempty"`
+	Depth    int64  `json:"depth,omitempty"`
+
+	// debits
+	License int64 `json:"license,omitempty"`
+	Price   int64 `json:"price,omitempty"`
+}
+
+// Totals sums up the ledger by source and sink
+type Totals struct {
+	Credited int64 `json:"credited"`
+	Debited  int64 `json:"debited"`
+	// coins credited from treasures dug at each depth
+	ByDepth map[int64]int64 `json:"by_depth"`
+	// coins debited per license price
+	ByPrice  map[int64]int64 `json:"by_price"`
+	Licenses int             `json:"licenses"`
+}
+
+// Ledger records where every coin came from and where it went. A nil
+// ledger records nothing.
+type Ledger struct {
+	mu      sync.Mutex
+	credits map[uint32]Entry
+	debits  map[uint32]Entry
+	// coins per treasure and per license
+	treasures map[string][]uint32
+	licenses  map[int64][]uint32
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{
+		credits:   map[uint32]Entry{},
+		debits:    map[uint32]Entry{},
+		treasures: map[string][]uint32{},
+		licenses:  map[int64][]uint32{},
+	}
+}
+
+// Credit records the coins a treasure dug at the depth was cashed for
+func (l *Ledger) Credit(coins []uint32, treasure string, depth int64) {
+	if l == nil || len(coins) == 0 {
+		return
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range coins {
+		l.credits[c] = Entry{Type: CreditEntry, Coin: c, Time: now, Treasure: treasure, Depth: depth}
+	}
+	l.treasures[treasure] = append(l.treasures[treasure], coins...)
+}
+
+// Debit records the coins paid for a license
+func (l *Ledger) Debit(coins []uint32, license, price int64) {
+	if l == nil || len(coins) == 0 {
+		return
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range coins {
+		l.debits[c] = Entry{Type: DebitEntry, Coin: c, Time: now, License: license, Price: price}
+	}
+	l.licenses[license] = append(l.licenses[license], coins...)
+}
+
+// Source returns the credit of a coin
+func (l *Ledger) Source(coin uint32) (Entry, bool) {
+	if l == nil {
+		return Entry{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.credits[coin]
+	return e, ok
+}
+
+// Sink returns the debit of a spent coin
+func (l *Ledger) Sink(coin uint32) (Entry, bool) {
+	if l == nil {
+		return Entry{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.debits[coin]
+	return e, ok
+}
+
+// TreasureCoins returns the coins a treasure was cashed for
+func (l *Ledger) TreasureCoins(treasure string) []uint32 {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]uint32(nil), l.treasures[treasure]...)
+}
+
+// LicenseCoins returns the coins paid for a license
+func (l *Ledger) LicenseCoins(license int64) []uint32 {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]uint32(nil), l.licenses[license]...)
+}
+
+func (l *Ledger) Totals() Totals {
+	t := Totals{ByDepth: map[int64]int64{}, ByPrice: map[int64]int64{}}
+	if l == nil {
+		return t
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.credits {
+		t.Credited++
+		t.ByDepth[e.Depth]++
+	}
+	for _, e := range l.debits {
+		t.Debited++
+		t.ByPrice[e.Price]++
+	}
+	t.Licenses = len(l.licenses)
+	return t
+}
+
+// Export writes all entries as JSONL ordered by time
+func (l *Ledger) Export(w io.Writer) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	entries := make([]Entry, 0, len(l.credits)+len(l.debits))
+	for _, e := range l.credits {
+		entries = append(entries, e)
+	}
+	for _, e := range l.debits {
+		entries = append(entries, e)
+	}
+	l.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+func (l *Ledger) ExportFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := l.Export(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadLedger calls f for every entry of an exported ledger
+func ReadLedger(r io.Reader, f func(Entry) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
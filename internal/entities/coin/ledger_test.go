@@ -0,0 +1,79 @@
+package coin
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLedgerTracksCoins(t *testing.T) {
+	l := NewLedger()
+	l.Credit([]uint32{1, 2, 3}, "t1", 2)
+	l.Credit([]uint32{4}, "t2", 5)
+	l.Debit([]uint32{1, 4}, 7, 2)
+
+	if e, ok := l.Source(4); !ok || e.Treasure != "t2" || e.Depth != 5 {
+		t.Fatalf("source of coin 4 = %+v, %v", e, ok)
+	}
+	if e, ok := l.Sink(1); !ok || e.License != 7 || e.Price != 2 {
+		t.Fatalf("sink of coin 1 = %+v, %v", e, ok)
+	}
+	if _, ok := l.Sink(2); ok {
+		t.Fatal("an unspent coin has a sink")
+	}
+	if got := l.TreasureCoins("t1"); !reflect.DeepEqual(got, []uint32{1, 2, 3}) {
+		t.Fatalf("coins of t1 = %v", got)
+	}
+	if got := l.LicenseCoins(7); !reflect.DeepEqual(got, []uint32{1, 4}) {
+		t.Fatalf("coins of license 7 = %v", got)
+	}
+
+	totals := l.Totals()
+	want := Totals{
+		Credited: 4,
+		Debited:  2,
+		ByDepth:  map[int64]int64{2: 3, 5: 1},
+		ByPrice:  map[int64]int64{2: 2},
+		Licenses: 1,
+	}
+	if !reflect.DeepEqual(totals, want) {
+		t.Fatalf("totals = %+v, want %+v", totals, want)
+	}
+}
+
+func TestLedgerExportRoundTrip(t *testing.T) {
+	l := NewLedger()
+	l.Credit([]uint32{1, 2}, "t1", 3)
+	l.Debit([]uint32{2}, 9, 1)
+
+	buf := &bytes.Buffer{}
+	if err := l.Export(buf); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	err := ReadLedger(buf, func(e Entry) error {
+		counts[e.Type]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[CreditEntry] != 2 || counts[DebitEntry] != 1 {
+		t.Fatalf("read back %v, want 2 credits and 1 debit", counts)
+	}
+	if err := ReadLedger(bytes.NewBufferString("{\n"), func(Entry) error { return nil }); err == nil {
+		t.Fatal("a broken line was read")
+	}
+}
+
+func TestNilLedger(t *testing.T) {
+	var l *Ledger
+	l.Credit([]uint32{1}, "t", 1)
+	l.Debit([]uint32{1}, 1, 1)
+	if _, ok := l.Source(1); ok {
+		t.Fatal("nil ledger has a source")
+	}
+	if totals := l.Totals(); totals.Credited != 0 || totals.ByDepth == nil {
+		t.Fatalf("nil ledger totals = %+v", totals)
+	}
+}
@@ -0,0 +1,45 @@
+package license
+
+// Batch is a run of permits of a single license, so the digs of one cell
+// don't spread over many licenses. Its permits are handed out as handles
+// one at a time, the ones left when the cell is done go back to the pool
+// with Release.
+type Batch struct {
+	lease   *lease
+	p       pool
+	permits []int64
+}
+
+// Len returns the permits not handed out yet
+func (b Batch) Len() int {
+	return len(b.permits)
+}
+
+// Next hands out the next permit, ok is false once the batch is used up
+func (b *Batch) Next() (h Handle, ok bool) {
+	if len(b.permits) == 0 {
+		return Handle{}, false
+	}
+	n := b.permits[0]
+	b.permits = b.permits[1:]
+	b.lease.take(n)
+	return Handle{
+		lease:  b.lease,
+		p:      b.p,
+		n:      n,
+		active: true,
+	}, true
+}
+
+// Release gives the permits not handed out back to the pool
+func (b *Batch) Release() {
+	if len(b.permits) == 0 {
+		return
+	}
+	permits := b.permits
+	b.permits = nil
+	for _, n := range permits {
+		b.lease.untake(n)
+	}
+	b.p.giveBack(b.lease, permits)
+}
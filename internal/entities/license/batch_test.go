@@ -0,0 +1,144 @@
+package license
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// forEachPool runs the test against the mutex and the sharded manager
+func forEachPool(t *testing.T, test func(t *testing.T, p Pool)) {
+	for _, shards := range []int{1, 4} {
+		t.Run(fmt.Sprintf("shards=%d", shards), func(t *testing.T) {
+			test(t, NewPool(1, shards, logger.Nop()))
+		})
+	}
+}
+
+func issue(t *testing.T, p Pool, id, digs int64) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	h, err := p.RequestAddContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Ok(id, digs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getBatch(t *testing.T, p Pool, size int) Batch {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	b, err := p.GetBatch(ctx, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestBatchReleaseGivesBackUnusedPermits(t *testing.T) {
+	forEachPool(t, func(t *testing.T, p Pool) {
+		issue(t, p, 1, 5)
+		b := getBatch(t, p, 3)
+		if b.Len() != 3 {
+			t.Fatalf("batch of %d permits, want 3", b.Len())
+		}
+		h, ok := b.Next()
+		if !ok || h.ID() != 1 {
+			t.Fatalf("handle of license %d, ok %v", h.ID(), ok)
+		}
+		h.Close()
+		b.Release()
+		b.Release()
+		if b.Len() != 0 {
+			t.Fatal("released batch still has permits")
+		}
+
+		// the two given back and the two never taken, the sharded
+		// manager may hand them out in separate batches
+		left := 4
+		for left > 0 {
+			rest := getBatch(t, p, 10)
+			if rest.Len() == 0 || rest.Len() > left {
+				t.Fatalf("batch of %d permits with %d left", rest.Len(), left)
+			}
+			left -= rest.Len()
+			for {
+				h, ok := rest.Next()
+				if !ok {
+					break
+				}
+				h.Close()
+			}
+		}
+		if s := p.Stats(); s.Active != 0 || s.DoubleCloses != 0 {
+			t.Fatalf("stats after closing every permit = %+v", s)
+		}
+		// the slot of the used up license is free again
+		issue(t, p, 2, 1)
+	})
+}
+
+func TestBatchReleaseKeepsLicenseUntilClosed(t *testing.T) {
+	forEachPool(t, func(t *testing.T, p Pool) {
+		issue(t, p, 1, 2)
+		b := getBatch(t, p, 2)
+		h, _ := b.Next()
+		b.Release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := p.RequestAddContext(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("a license with an open permit gave up its slot: %v", err)
+		}
+
+		other := getBatch(t, p, 2)
+		if other.Len() != 1 {
+			t.Fatalf("batch of %d permits, want the one given back", other.Len())
+		}
+		h2, _ := other.Next()
+		h.Close()
+		h2.Close()
+		issue(t, p, 2, 1)
+	})
+}
+
+func TestGetBatchCancelled(t *testing.T) {
+	forEachPool(t, func(t *testing.T, p Pool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error)
+		go func() {
+			_, err := p.GetBatch(ctx, 2)
+			errs <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		select {
+		case err := <-errs:
+			if err != context.Canceled {
+				t.Fatalf("err = %v, want context.Canceled", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("GetBatch ignored the cancellation")
+		}
+	})
+}
+
+func TestGetBatchPrefersFittingLicense(t *testing.T) {
+	p := NewManager(3, logger.Nop())
+	issue(t, p, 1, 10)
+	issue(t, p, 2, 3)
+	issue(t, p, 3, 1)
+	if b := getBatch(t, p, 3); b.Len() != 3 || b.lease.id != 2 {
+		t.Fatalf("batch of %d permits of license %d, want 3 of license 2", b.Len(), b.lease.id)
+	}
+	if b := getBatch(t, p, 20); b.Len() != 10 || b.lease.id != 1 {
+		t.Fatalf("batch of %d permits of license %d, want 10 of license 1", b.Len(), b.lease.id)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !leasedebug
+// +build !leasedebug
+
+package license
+
+// debugLeases keeps the stacks permits are handed out at, build with the
+// leasedebug tag to turn it on
+const debugLeases = false
@@ -0,0 +1,6 @@
+//go:build leasedebug
+// +build leasedebug
+
+package license
+
+const debugLeases = true
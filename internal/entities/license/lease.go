@@ -0,0 +1,257 @@
+package license
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+const (
+	// a permit slot that wasn't handed out yet
+	permitFree int64 = 0
+	// a permit slot whose handle was closed
+	permitClosed int64 = -1
+)
+
+// lease tracks the permits of one license. Every permit is owned by the
+// handle it was handed out with until the handle is closed, the license is
+// released once all of its permits are closed.
+type lease struct {
+	id     int64
+	digs   int64
+	issued time.Time
+	// permits not closed yet
+	open int64
+	// per permit the unix nanos it was handed out at, or one of the
+	// permitFree and permitClosed states
+	permits []int64
+	// where the permits were handed out, only kept with the leasedebug tag
+	mu     sync.Mutex
+	stacks []string
+}
+
+func newLease(id, digs int64) *lease {
+	l := &lease{
+		id:      id,
+		digs:    digs,
+		issued:  time.Now(),
+		open:    digs,
+		permits: make([]int64, digs),
+	}
+	if debugLeases {
+		l.stacks = make([]string, digs)
+	}
+	return l
+}
+
+func (l *lease) take(n int64) {
+	atomic.StoreInt64(&l.permits[n], time.Now().UnixNano())
+	if debugLeases {
+		s := stack()
+		l.mu.Lock()
+		l.stacks[n] = s
+		l.mu.Unlock()
+	}
+}
+
+// untake frees a permit that was handed out but not used
+func (l *lease) untake(n int64) {
+	atomic.StoreInt64(&l.permits[n], permitFree)
+}
+
+// close closes the permit and reports whether it was open, last is set
+// when it was the last open permit of the lease
+func (l *lease) close(n int64) (ok, last bool) {
+	for {
+		t := atomic.LoadInt64(&l.permits[n])
+		if t == permitClosed || t == permitFree {
+			return false, false
+		}
+		if atomic.CompareAndSwapInt64(&l.permits[n], t, permitClosed) {
+			return true, atomic.AddInt64(&l.open, -1) == 0
+		}
+	}
+}
+
+func (l *lease) takenStack(n int64) string {
+	if !debugLeases {
+		return ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stacks[n]
+}
+
+func stack() string {
+	if !debugLeases {
+		return ""
+	}
+	buf := make([]byte, 4096)
+	return string(buf[:runtime.Stack(buf, false)])
+}
+
+// LeaseInfo is the permit accounting of a live license
+type LeaseInfo struct {
+	ID   int64 `json:"id"`
+	Digs int64 `json:"digs"`
+	// permits handed out, closed ones included
+	Taken int64 `json:"taken"`
+	// permits closed, the digs used as far as the pool knows
+	Closed int64     `json:"closed"`
+	Issued time.Time `json:"issued"`
+}
+
+func (l *lease) info() LeaseInfo {
+	i := LeaseInfo{ID: l.id, Digs: l.digs, Issued: l.issued}
+	for n := range l.permits {
+		switch atomic.LoadInt64(&l.permits[n]) {
+		case permitFree:
+		case permitClosed:
+			i.Taken++
+			i.Closed++
+		default:
+			i.Taken++
+		}
+	}
+	return i
+}
+
+// Leak is a permit that is held longer than expected
+type Leak struct {
+	License int64  `json:"license"`
+	Permit  int64  `json:"permit"`
+	Age     string `json:"age"`
+	// where the permit was handed out, only with the leasedebug tag
+	Stack string `json:"stack,omitempty"`
+}
+
+// DoubleClose is a handle that was closed again, or a copy of it closed
+type DoubleClose struct {
+	License int64     `json:"license"`
+	Permit  int64     `json:"permit"`
+	Time    time.Time `json:"time"`
+	// where the permit was handed out and closed again, only with the
+	// leasedebug tag
+	Taken  string `json:"taken,omitempty"`
+	Closed string `json:"closed,omitempty"`
+}
+
+// Audit is what a pool knows about misused handles and its live licenses
+type Audit struct {
+	DoubleCloses int64 `json:"double_closes"`
+	// the latest double closes
+	Recent []DoubleClose `json:"recent"`
+	Leaks  []Leak        `json:"leaks"`
+	Leases []LeaseInfo   `json:"leases"`
+}
+
+// double closes kept for the audit
+const recentDoubleCloses = 16
+
+// leases is the registry of the live leases of a pool
+type leases struct {
+	mu           sync.Mutex
+	log          *logger.Logger
+	live         map[int64]*lease
+	doubleCloses int64
+	recent       []DoubleClose
+}
+
+func newLeases(log *logger.Logger) *leases {
+	return &leases{log: log, live: map[int64]*lease{}}
+}
+
+func (r *leases) add(l *lease) {
+	r.mu.Lock()
+	r.live[l.id] = l
+	r.mu.Unlock()
+}
+
+func (r *leases) remove(l *lease) {
+	r.mu.Lock()
+	delete(r.live, l.id)
+	r.mu.Unlock()
+}
+
+// handedOut returns the live leases with all permits handed out
+func (r *leases) handedOut() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := 0
+	for _, l := range r.live {
+		if i := l.info(); i.Taken == i.Digs {
+			c++
+		}
+	}
+	return c
+}
+
+func (r *leases) count() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.doubleCloses
+}
+
+func (r *leases) doubleClose(l *lease, n int64) {
+	d := DoubleClose{License: l.id, Permit: n, Time: time.Now()}
+	if debugLeases {
+		d.Taken = l.takenStack(n)
+		d.Closed = stack()
+	}
+	r.log.Warn("permit closed twice",
+		logger.F("license_id", d.License),
+		logger.F("permit", d.Permit),
+		logger.F("taken", d.Taken),
+		logger.F("closed", d.Closed),
+	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.doubleCloses++
+	if len(r.recent) == recentDoubleCloses {
+		r.recent = append(r.recent[:0], r.recent[1:]...)
+	}
+	r.recent = append(r.recent, d)
+}
+
+// audit reports the permits held longer than maxAge as leaks, none if
+// maxAge isn't positive
+func (r *leases) audit(maxAge time.Duration) Audit {
+	r.mu.Lock()
+	a := Audit{
+		DoubleCloses: r.doubleCloses,
+		Recent:       append([]DoubleClose(nil), r.recent...),
+	}
+	live := make([]*lease, 0, len(r.live))
+	for _, l := range r.live {
+		live = append(live, l)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(live, func(i, j int) bool { return live[i].id < live[j].id })
+	now := time.Now()
+	for _, l := range live {
+		a.Leases = append(a.Leases, l.info())
+		if maxAge <= 0 {
+			continue
+		}
+		for n := range l.permits {
+			t := atomic.LoadInt64(&l.permits[n])
+			if t == permitFree || t == permitClosed {
+				continue
+			}
+			if age := now.Sub(time.Unix(0, t)); age > maxAge {
+				a.Leaks = append(a.Leaks, Leak{
+					License: l.id,
+					Permit:  int64(n),
+					Age:     age.String(),
+					Stack:   l.takenStack(int64(n)),
+				})
+			}
+		}
+	}
+	return a
+}
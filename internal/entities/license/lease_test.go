@@ -0,0 +1,67 @@
+package license
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDoubleCloseIsAudited(t *testing.T) {
+	forEachPool(t, func(t *testing.T, p Pool) {
+		issue(t, p, 1, 2)
+		b := getBatch(t, p, 2)
+		h, _ := b.Next()
+		copied := h
+		h.Close()
+		copied.Close()
+		h.Close()
+
+		a := p.Audit(0)
+		if a.DoubleCloses != 2 || p.Stats().DoubleCloses != 2 {
+			t.Fatalf("double closes = %d, want 2", a.DoubleCloses)
+		}
+		if len(a.Recent) != 2 || a.Recent[0].License != 1 || a.Recent[0].Permit != h.n {
+			t.Fatalf("recent double closes = %+v", a.Recent)
+		}
+		// the double closes don't count as used digs
+		if len(a.Leases) != 1 || a.Leases[0].Taken != 2 || a.Leases[0].Closed != 1 {
+			t.Fatalf("leases = %+v, want 2 permits taken and 1 closed", a.Leases)
+		}
+
+		last, _ := b.Next()
+		last.Close()
+		if a := p.Audit(0); len(a.Leases) != 0 {
+			t.Fatalf("leases after closing every permit = %+v", a.Leases)
+		}
+		// closing after the license is released doesn't release it again
+		last.Close()
+		issue(t, p, 2, 1)
+		if s := p.Stats(); s.Active != 1 || s.DoubleCloses != 3 {
+			t.Fatalf("stats = %+v, want 1 active license and 3 double closes", s)
+		}
+	})
+}
+
+func TestAuditReportsLeaks(t *testing.T) {
+	forEachPool(t, func(t *testing.T, p Pool) {
+		issue(t, p, 1, 3)
+		b := getBatch(t, p, 1)
+		time.Sleep(5 * time.Millisecond)
+
+		if a := p.Audit(0); len(a.Leaks) != 0 {
+			t.Fatalf("leaks without a max age: %+v", a.Leaks)
+		}
+		if a := p.Audit(time.Hour); len(a.Leaks) != 0 {
+			t.Fatalf("leaks of a fresh permit: %+v", a.Leaks)
+		}
+		a := p.Audit(time.Millisecond)
+		if len(a.Leaks) != 1 || a.Leaks[0].License != 1 {
+			t.Fatalf("leaks = %+v, want the permit of license 1", a.Leaks)
+		}
+
+		h, _ := b.Next()
+		h.Close()
+		if a := p.Audit(time.Millisecond); len(a.Leaks) != 0 {
+			t.Fatalf("a closed permit leaks: %+v", a.Leaks)
+		}
+	})
+}
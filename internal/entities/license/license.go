@@ -1,33 +1,70 @@
 package license
 
-import (
-	"sync"
-)
+type license struct {
+	// digs left to hand out
+	digs  int64
+	id    int64
+	lease *lease
+	// the next permit that was never handed out
+	fresh int64
+	// permits given back by batches, handed out first
+	returned []int64
+}
 
-var idsBuffer = &sync.Pool{
-	New: func() interface{} {
-		return new(int64)
-	},
+func newLicense(id, digs int64) license {
+	return license{
+		digs:  digs,
+		id:    id,
+		lease: newLease(id, digs),
+	}
 }
 
-type license struct {
-	digs int64
-	id   int64
-	desc *int64
+// returnedLicense is an entry for the permits a batch gave back
+func returnedLicense(l *lease, permits []int64) license {
+	return license{
+		digs:     int64(len(permits)),
+		id:       l.id,
+		lease:    l,
+		fresh:    l.digs,
+		returned: permits,
+	}
 }
 
-func (l license) Close() {
-	idsBuffer.Put(l.desc)
-	l.desc = nil
-	l.digs = -1
+// next picks the permit to hand out, l.digs must be positive
+func (l *license) next() int64 {
+	l.digs--
+	if k := len(l.returned); k > 0 {
+		n := l.returned[k-1]
+		l.returned = l.returned[:k-1]
+		return n
+	}
+	n := l.fresh
+	l.fresh++
+	return n
 }
 
-func newLicense(id, digs int64) license {
-	ptr := idsBuffer.Get().(*int64)
-	*ptr = digs
-	return license{
-		digs: digs,
-		id:   id,
-		desc: ptr,
+// take hands out the next permit of the license, l.digs must be positive
+func (l *license) take(p pool) Handle {
+	n := l.next()
+	l.lease.take(n)
+	return Handle{
+		lease:  l.lease,
+		p:      p,
+		n:      n,
+		active: true,
+	}
+}
+
+// takeBatch hands out up to size permits, l.digs must be positive
+func (l *license) takeBatch(p pool, size int64) Batch {
+	if size > l.digs {
+		size = l.digs
+	}
+	b := Batch{lease: l.lease, p: p, permits: make([]int64, size)}
+	for i := range b.permits {
+		n := l.next()
+		l.lease.take(n)
+		b.permits[i] = n
 	}
+	return b
 }
@@ -1,15 +1,58 @@
 package license
 
 import (
+	"context"
 	"errors"
 	"sync"
-	"sync/atomic"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/condwait"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
 )
 
+// Pool hands out dig permits of issued licenses and limits how many
+// licenses are active at once
+type Pool interface {
+	// RequestAdd blocks until a license can be issued
+	RequestAdd() AddHandle
+	// RequestAddContext is RequestAdd that gives up once ctx is done
+	RequestAddContext(ctx context.Context) (AddHandle, error)
+	// Get blocks until a dig permit is available
+	Get() Handle
+	// GetContext is Get that gives up once ctx is done
+	GetContext(ctx context.Context) (Handle, error)
+	// GetBatch waits for up to size permits of a single license
+	GetBatch(ctx context.Context, size int) (Batch, error)
+	Stats() Stats
+	// Audit reports double closed handles, permits held longer than
+	// maxAge and the accounting of the live licenses
+	Audit(maxAge time.Duration) Audit
+}
+
+// pool is what handles report back to
+type pool interface {
+	release(l *lease)
+	add(id, digs int64) error
+	fail()
+	doubleClose(l *lease, n int64)
+	giveBack(l *lease, permits []int64)
+}
+
+// NewPool returns the mutex based manager for a single shard and the
+// sharded one otherwise
+func NewPool(maxLicenses, shards int, log *logger.Logger) Pool {
+	if shards <= 1 {
+		return NewManager(maxLicenses, log)
+	}
+	return NewShardedManager(maxLicenses, shards, log)
+}
+
+// Handle owns one dig permit of a license until it is closed. Closing it,
+// or a copy of it, again is reported by the pool's audit.
 type Handle struct {
-	desc   *int64
-	m      *Manager
-	id     int64
+	lease  *lease
+	p      pool
+	n      int64
 	active bool
 }
 
@@ -18,19 +61,30 @@ func (h Handle) Active() bool {
 }
 
 func (h *Handle) Close() {
+	if h.lease == nil {
+		return
+	}
 	h.active = false
-	if atomic.AddInt64(h.desc, -1) == 0 {
-		h.m.deleteLicense(h.id)
+	ok, last := h.lease.close(h.n)
+	if !ok {
+		h.p.doubleClose(h.lease, h.n)
+		return
+	}
+	if last {
+		h.p.release(h.lease)
 	}
 }
 
 func (h Handle) ID() int64 {
-	return h.id
+	if h.lease == nil {
+		return 0
+	}
+	return h.lease.id
 }
 
 type AddHandle struct {
 	done bool
-	m *Manager
+	p    pool
 }
 
 func (h *AddHandle) Ok(id, digs int64) error {
@@ -39,7 +93,7 @@ func (h *AddHandle) Ok(id, digs int64) error {
 	}
 	h.done = true
 
-	return h.m.add(id, digs)
+	return h.p.add(id, digs)
 }
 
 func (h *AddHandle) Fail() {
@@ -47,10 +101,7 @@ func (h *AddHandle) Fail() {
 		return
 	}
 	h.done = true
-	h.m.mu.Lock()
-	h.m.licenseCounter--
-	h.m.addCond.Signal()
-	h.m.mu.Unlock()
+	h.p.fail()
 }
 
 
@@ -62,13 +113,33 @@ type Manager struct {
 	licensesInUse    map[int64]license
 	deletedLicenses  map[int64]struct{}
 	mu               sync.RWMutex
+	leases           *leases
+}
+
+func (m *Manager) release(l *lease) {
+	m.deleteLicense(l.id)
+	m.leases.remove(l)
+}
+
+func (m *Manager) doubleClose(l *lease, n int64) {
+	m.leases.doubleClose(l, n)
+}
+
+func (m *Manager) Audit(maxAge time.Duration) Audit {
+	return m.leases.audit(maxAge)
+}
+
+func (m *Manager) fail() {
+	m.mu.Lock()
+	m.licenseCounter--
+	m.addCond.Signal()
+	m.mu.Unlock()
 }
 
 func (m *Manager) deleteLicense(id int64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if l, ok := m.licensesInUse[id]; ok {
-		l.Close()
+	if _, ok := m.licensesInUse[id]; ok {
 		delete(m.licenses, id)
 		delete(m.licensesInUse, id)
 		m.deletedLicenses[id] = struct{}{}
@@ -78,16 +149,22 @@ func (m *Manager) deleteLicense(id int64) {
 }
 
 func (m *Manager) RequestAdd() AddHandle {
+	h, _ := m.RequestAddContext(context.Background())
+	return h
+}
+
+func (m *Manager) RequestAddContext(ctx context.Context) (AddHandle, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	for m.licenseCounter >= m.maxLicenses {
-		m.addCond.Wait()
+	err := condwait.Wait(ctx, m.addCond, func() bool { return m.licenseCounter < m.maxLicenses })
+	if err != nil {
+		return AddHandle{}, err
 	}
 	m.licenseCounter++
 	return AddHandle{
 		done: false,
-		m:    m,
-	}
+		p:    m,
+	}, nil
 }
 
 func (m *Manager) add(id, digs int64) error {
@@ -108,24 +185,27 @@ func (m *Manager) add(id, digs int64) error {
 	if _, ok := m.deletedLicenses[id]; ok {
 		return errors.New("license already registered")
 	}
-	m.licenses[id] = newLicense(id, digs)
+	l := newLicense(id, digs)
+	m.leases.add(l.lease)
+	m.licenses[id] = l
 	m.getCond.Broadcast()
 	return nil
 }
 
 func (m *Manager) Get() Handle {
+	h, _ := m.GetContext(context.Background())
+	return h
+}
+
+func (m *Manager) GetContext(ctx context.Context) (Handle, error) {
 	m.mu.Lock()
-	for len(m.licenses) == 0 {
-		m.getCond.Wait()
+	defer m.mu.Unlock()
+	if err := condwait.Wait(ctx, m.getCond, func() bool { return len(m.licenses) > 0 }); err != nil {
+		return Handle{}, err
 	}
+	var h Handle
 	for _, l := range m.licenses {
-		l.digs--
-		h := Handle{
-			desc:   l.desc,
-			m:      m,
-			id:     l.id,
-			active: true,
-		}
+		h = l.take(m)
 
 		if l.digs <= 0 {
 			m.licensesInUse[l.id] = l
@@ -133,14 +213,94 @@ func (m *Manager) Get() Handle {
 		} else {
 			m.licenses[l.id] = l
 		}
-		m.mu.Unlock()
-		return h
+		break
 	}
-	m.mu.Unlock()
-	return m.Get()
+	return h, nil
+}
+
+// GetBatch takes the permits from the license that fits the size best, or
+// the one with the most digs left if none has enough
+func (m *Manager) GetBatch(ctx context.Context, size int) (Batch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := condwait.Wait(ctx, m.getCond, func() bool { return len(m.licenses) > 0 }); err != nil {
+		return Batch{}, err
+	}
+	want := int64(size)
+	if want < 1 {
+		want = 1
+	}
+	var best license
+	for _, l := range m.licenses {
+		switch {
+		case best.lease == nil:
+			best = l
+		case best.digs >= want:
+			if l.digs >= want && l.digs < best.digs {
+				best = l
+			}
+		case l.digs > best.digs:
+			best = l
+		}
+	}
+	b := best.takeBatch(m, want)
+	if best.digs <= 0 {
+		m.licensesInUse[best.id] = best
+		delete(m.licenses, best.id)
+	} else {
+		m.licenses[best.id] = best
+	}
+	return b, nil
+}
+
+func (m *Manager) giveBack(l *lease, permits []int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.licenses[l.id]; ok {
+		e.returned = append(e.returned, permits...)
+		e.digs += int64(len(permits))
+		m.licenses[l.id] = e
+	} else if _, ok := m.licensesInUse[l.id]; ok {
+		delete(m.licensesInUse, l.id)
+		m.licenses[l.id] = returnedLicense(l, permits)
+	} else {
+		return
+	}
+	m.getCond.Broadcast()
+}
+
+type Stats struct {
+	MaxLicenses int `json:"max_licenses"`
+	// licenses that are issued or being issued
+	Active int `json:"active"`
+	// licenses that still have digs to hand out
+	Available int `json:"available"`
+	// licenses with all digs handed out, but not closed yet
+	InUse    int   `json:"in_use"`
+	Deleted  int   `json:"deleted"`
+	DigsLeft int64 `json:"digs_left"`
+	// handles closed more than once
+	DoubleCloses int64 `json:"double_closes"`
+}
+
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := Stats{
+		MaxLicenses: m.maxLicenses,
+		Active:      m.licenseCounter,
+		Available:   len(m.licenses),
+		InUse:       len(m.licensesInUse),
+		Deleted:     len(m.deletedLicenses),
+	}
+	for _, l := range m.licenses {
+		s.DigsLeft += l.digs
+	}
+	s.DoubleCloses = m.leases.count()
+	return s
 }
 
-func NewManager(maxLicenses int) *Manager {
+func NewManager(maxLicenses int, log *logger.Logger) *Manager {
 
 	m := &Manager{
 		maxLicenses:     maxLicenses,
@@ -149,6 +309,7 @@ func NewManager(maxLicenses int) *Manager {
 		licensesInUse:   map[int64]license{},
 		deletedLicenses: map[int64]struct{}{},
 		mu:              sync.RWMutex{},
+		leases:          newLeases(log),
 	}
 
 	m.getCond = sync.NewCond(&m.mu)
@@ -0,0 +1,73 @@
+package license
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+const (
+	benchDiggers  = 1500
+	benchIssuers  = 60
+	benchLicenses = 10
+	benchDigs     = 50
+)
+
+// BenchmarkPool compares the single lock manager with the sharded one under
+// many concurrent diggers. Issuers keep the pool full of licenses, every
+// digger takes a permit and closes it right away, as the real diggers do
+// after a dig.
+func BenchmarkPool(b *testing.B) {
+	for _, shards := range []int{1, 4, 16, 64} {
+		name := fmt.Sprintf("sharded/%d", shards)
+		if shards == 1 {
+			name = "mutex"
+		}
+		b.Run(name, func(b *testing.B) {
+			benchmarkPool(b, shards)
+		})
+	}
+}
+
+func benchmarkPool(b *testing.B, shards int) {
+	pool := NewPool(benchLicenses, shards, logger.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	var ids int64
+	for i := 0; i < benchIssuers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				h, err := pool.RequestAddContext(ctx)
+				if err != nil {
+					return
+				}
+				if err := h.Ok(atomic.AddInt64(&ids, 1), benchDigs); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	procs := runtime.GOMAXPROCS(0)
+	b.SetParallelism((benchDiggers + procs - 1) / procs)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h := pool.Get()
+			h.Close()
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"container/heap"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
 	"sync"
+	"time"
 )
 
 type intRevSlice []int64
@@ -24,13 +25,43 @@ type Price struct {
 	RealAmount int64
 	Digs int64
 	Failed bool
+	// time it took to issue the license
+	Latency time.Duration
 	experimental bool
+	// picked by a pricer that learns from every price it hands out
+	sampled bool
 }
 
 func (p *Price) Experimental() bool {
 	return p.experimental
 }
 
+// Free reports whether the license is issued without coins
+func (p *Price) Free() bool {
+	return p.CoinsAmount == 0
+}
+
+// TierStats sums up the licenses issued at free or paid prices
+type TierStats struct {
+	Licenses int64         `json:"licenses"`
+	Digs     int64         `json:"digs"`
+	Latency  time.Duration `json:"latency"`
+}
+
+func (t *TierStats) add(price Price) {
+	t.Licenses++
+	t.Digs += price.Digs
+	t.Latency += price.Latency
+}
+
+// Throughput returns digs per second spent issuing licenses
+func (t TierStats) Throughput() float64 {
+	if t.Latency <= 0 {
+		return 0
+	}
+	return float64(t.Digs) / t.Latency.Seconds()
+}
+
 type sortedPrices []Price
 
 func (s *sortedPrices) Swap(i, j int) {
@@ -67,6 +98,13 @@ type PriceList struct {
 	results map[int64]int64
 	experimentalAmounts []int64
 	sortedPrices *sortedPrices
+	// prices whose results come from a saved table
+	seeded map[int64]bool
+	// when the result of each price was measured
+	measured map[int64]time.Time
+	free, paid TierStats
+	// licenses of each tier needed before comparing them
+	minTierSamples int64
 	g float64
 	k int64
 
@@ -89,6 +127,9 @@ func NewPriceList(config config.Config) *PriceList {
 		results:             map[int64]int64{},
 		experimentalAmounts: arr,
 		sortedPrices:        (*sortedPrices)(&[]Price{}),
+		seeded:              map[int64]bool{},
+		measured:            map[int64]time.Time{},
+		minTierSamples:      int64(config.App.License.Free.MinSamples),
 		g:                   config.App.License.PriceList.G,
 		k:                   int64(config.App.License.PriceList.K),
 		counter:             0,
@@ -99,6 +140,14 @@ func NewPriceList(config config.Config) *PriceList {
 func (p *PriceList) Commit(price Price) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if !price.Failed {
+		if price.Free() {
+			p.free.add(price)
+			// free licenses are tracked on their own, not as an experiment
+			return
+		}
+		p.paid.add(price)
+	}
 	if !price.experimental {
 		return
 	}
@@ -106,8 +155,29 @@ func (p *PriceList) Commit(price Price) {
 		heap.Push(p.sortable(), price.CoinsAmount)
 		return
 	}
+	p.unseed(price.CoinsAmount)
 	heap.Push(p.sortedPrices, price)
 	p.results[price.CoinsAmount] = price.Digs
+	p.measured[price.CoinsAmount] = time.Now()
+}
+
+// Tiers returns the stats of free and paid licenses
+func (p *PriceList) Tiers() (free, paid TierStats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.free, p.paid
+}
+
+// freeIsFaster reports whether free licenses bring at least as many digs per
+// second of issuing as paid ones, p.mu must be held. The coins paid licenses
+// cost are left out on purpose: a free tier that is as fast is better for
+// any price, while a slower one may still be worth skipping paid licenses
+// for, which is up to the economics estimator that knows what a dig earns.
+func (p *PriceList) freeIsFaster() bool {
+	if p.minTierSamples <= 0 || p.free.Licenses < p.minTierSamples || p.paid.Licenses < p.minTierSamples {
+		return false
+	}
+	return p.free.Throughput() >= p.paid.Throughput()
 }
 
 func (p *PriceList) Map() map[int64]int64 {
@@ -140,30 +210,34 @@ func (p *PriceList) calculateOptimalPrice(max int64) int64 {
 	return amount
 }
 
+// optimalPrice returns the best known price, free if free licenses are faster
+func (p *PriceList) optimalPrice(coinsAmount int64) Price {
+	if p.freeIsFaster() {
+		return Price{}
+	}
+	return Price{
+		CoinsAmount:  p.calculateOptimalPrice(coinsAmount),
+		experimental: false,
+	}
+}
+
 func (p *PriceList) Next(coinsAmount int64) Price {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if coinsAmount <= 0 {
+		return Price{}
+	}
 	p.counter++
 	if p.counter % p.k == 0 {
 		if len(p.experimentalAmounts) == 0 {
-			return Price{
-				CoinsAmount: p.calculateOptimalPrice(coinsAmount),
-				RealAmount:  0,
-				Digs:        0,
-				experimental: false,
-			}
+			return p.optimalPrice(coinsAmount)
 		}
 
 		c := heap.Pop(p.sortable()).(int64)
 
 		if coinsAmount < c {
 			heap.Push(p.sortable(), c)
-			return Price{
-				CoinsAmount: p.calculateOptimalPrice(coinsAmount),
-				RealAmount:  0,
-				Digs:        0,
-				experimental: false,
-			}
+			return p.optimalPrice(coinsAmount)
 		}
 
 		return Price{
@@ -173,11 +247,6 @@ func (p *PriceList) Next(coinsAmount int64) Price {
 			experimental: true,
 		}
 	} else {
-		return Price{
-			CoinsAmount: p.calculateOptimalPrice(coinsAmount),
-			RealAmount:  0,
-			Digs:        0,
-			experimental: false,
-		}
+		return p.optimalPrice(coinsAmount)
 	}
 }
\ No newline at end of file
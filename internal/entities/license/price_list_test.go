@@ -0,0 +1,58 @@
+package license
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func newFreeTierPriceList(minSamples int) *PriceList {
+	var cfg config.Config
+	cfg.App.License.PriceList.Experiments = 10
+	// no experiments, every price is the optimal one
+	cfg.App.License.PriceList.K = 1 << 30
+	cfg.App.License.Free.MinSamples = minSamples
+	return NewPriceList(cfg)
+}
+
+func TestPriceListPicksFasterFreeTier(t *testing.T) {
+	p := newFreeTierPriceList(2)
+	p.Commit(Price{CoinsAmount: 4, RealAmount: 4, Digs: 10, experimental: true, Latency: time.Second})
+
+	// too few samples to compare the tiers
+	p.Commit(Price{Digs: 3, Latency: time.Second})
+	if price := p.Next(100); price.Free() {
+		t.Fatal("free tier picked before min samples")
+	}
+	p.Commit(Price{Digs: 3, Latency: time.Second})
+	p.Commit(Price{CoinsAmount: 4, RealAmount: 4, Digs: 10, Latency: 9 * time.Second})
+
+	free, paid := p.Tiers()
+	if free.Licenses != 2 || free.Digs != 6 || paid.Licenses != 2 || paid.Digs != 20 {
+		t.Fatalf("tiers = %+v %+v", free, paid)
+	}
+	// 3 digs per second for free against 2 paid
+	if price := p.Next(100); !price.Free() || price.Experimental() {
+		t.Fatalf("got %+v, want a free license", price)
+	}
+
+	p.Commit(Price{CoinsAmount: 4, RealAmount: 4, Digs: 100, Latency: time.Second})
+	if price := p.Next(100); price.Free() {
+		t.Fatal("free tier picked while paid licenses are faster")
+	}
+}
+
+func TestPriceListFreeTierDisabled(t *testing.T) {
+	p := newFreeTierPriceList(0)
+	for i := 0; i < 5; i++ {
+		p.Commit(Price{Digs: 100, Latency: time.Millisecond})
+		p.Commit(Price{CoinsAmount: 1, RealAmount: 1, Digs: 1, Latency: time.Second})
+	}
+	if price := p.Next(100); price.Free() {
+		t.Fatal("free tier picked with min samples 0")
+	}
+	if m := p.Map(); len(m) != 0 {
+		t.Fatalf("free and regular licenses were recorded as experiments: %v", m)
+	}
+}
@@ -0,0 +1,155 @@
+package license
+
+import (
+	"container/heap"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PriceTable is what a price list learned, saved between runs
+type PriceTable struct {
+	SavedAt time.Time `json:"saved_at"`
+	// price -> digs
+	Digs map[int64]int64 `json:"digs"`
+	// price -> when its digs were last measured, tables saved before it
+	// was added were measured at SavedAt
+	Measured map[int64]time.Time `json:"measured,omitempty"`
+}
+
+// MeasuredAt returns when the digs of the price were last measured
+func (t PriceTable) MeasuredAt(price int64) time.Time {
+	if at, ok := t.Measured[price]; ok {
+		return at
+	}
+	return t.SavedAt
+}
+
+// Confidence halves every halfLife since the price was measured
+func (t PriceTable) Confidence(price int64, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return 1
+	}
+	age := time.Since(t.MeasuredAt(price))
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// Prune returns the table without the prices less confident than min
+func (t PriceTable) Prune(halfLife time.Duration, min float64) PriceTable {
+	pruned := PriceTable{
+		SavedAt:  t.SavedAt,
+		Digs:     map[int64]int64{},
+		Measured: map[int64]time.Time{},
+	}
+	for price, digs := range t.Digs {
+		if t.Confidence(price, halfLife) < min {
+			continue
+		}
+		pruned.Digs[price] = digs
+		pruned.Measured[price] = t.MeasuredAt(price)
+	}
+	return pruned
+}
+
+// Save writes the table atomically
+func (t PriceTable) Save(path string) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func LoadPriceTable(path string) (PriceTable, error) {
+	var t PriceTable
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+// Table returns the learned prices, seeded ones that weren't measured
+// again keep the time they were measured at in the saved table
+func (p *PriceList) Table() PriceTable {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := PriceTable{
+		SavedAt:  time.Now(),
+		Digs:     map[int64]int64{},
+		Measured: map[int64]time.Time{},
+	}
+	for price, digs := range p.results {
+		t.Digs[price] = digs
+		t.Measured[price] = p.measured[price]
+	}
+	return t
+}
+
+// Seed uses a saved table until the prices are measured again. Seeded
+// prices at least as confident as reverify are not experimented with
+// anymore.
+func (p *PriceList) Seed(t PriceTable, halfLife time.Duration, reverify float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	confident := map[int64]bool{}
+	for price, digs := range t.Digs {
+		if _, ok := p.results[price]; ok {
+			continue
+		}
+		p.results[price] = digs
+		p.measured[price] = t.MeasuredAt(price)
+		p.seeded[price] = true
+		confident[price] = t.Confidence(price, halfLife) >= reverify
+		heap.Push(p.sortedPrices, Price{
+			CoinsAmount:  price,
+			RealAmount:   price,
+			Digs:         digs,
+			experimental: true,
+		})
+	}
+	amounts := p.experimentalAmounts[:0]
+	for _, amount := range p.experimentalAmounts {
+		if !confident[amount] {
+			amounts = append(amounts, amount)
+		}
+	}
+	p.experimentalAmounts = amounts
+	heap.Init(p.sortable())
+}
+
+// unseed drops the seeded result of the price, p.mu must be held
+func (p *PriceList) unseed(price int64) {
+	if !p.seeded[price] {
+		return
+	}
+	delete(p.seeded, price)
+	prices := (*p.sortedPrices)[:0]
+	for _, prc := range *p.sortedPrices {
+		if prc.CoinsAmount != price {
+			prices = append(prices, prc)
+		}
+	}
+	*p.sortedPrices = prices
+	heap.Init(p.sortedPrices)
+}
@@ -0,0 +1,82 @@
+package license
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func newPriceList(experiments int) *PriceList {
+	var cfg config.Config
+	cfg.App.License.PriceList.Experiments = experiments
+	return NewPriceList(cfg)
+}
+
+func TestPriceTableKeepsSeededMeasurementTime(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	p := newPriceList(10)
+	p.Seed(PriceTable{
+		SavedAt: old,
+		Digs:    map[int64]int64{1: 3, 4: 5},
+	}, time.Hour, 0)
+	p.Commit(Price{CoinsAmount: 4, RealAmount: 4, Digs: 6, experimental: true})
+
+	table := p.Table()
+	if !table.MeasuredAt(1).Equal(old) {
+		t.Fatalf("seeded price measured at %v, want %v", table.MeasuredAt(1), old)
+	}
+	if table.MeasuredAt(4).Before(time.Now().Add(-time.Minute)) {
+		t.Fatalf("measured price keeps the seeded time %v", table.MeasuredAt(4))
+	}
+	if table.Digs[4] != 6 {
+		t.Fatalf("digs of the measured price = %d, want 6", table.Digs[4])
+	}
+
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := table.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPriceTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := loaded.Confidence(1, 24*time.Hour); c > 0.26 {
+		t.Fatalf("confidence of a price measured two half-lives ago = %v", c)
+	}
+	if c := loaded.Confidence(4, 24*time.Hour); c < 0.99 {
+		t.Fatalf("confidence of a fresh price = %v", c)
+	}
+	pruned := loaded.Prune(24*time.Hour, 0.5)
+	if _, ok := pruned.Digs[1]; ok {
+		t.Fatal("stale price survived pruning")
+	}
+	if pruned.Digs[4] != 6 {
+		t.Fatal("fresh price was pruned")
+	}
+}
+
+func TestPriceTableReverify(t *testing.T) {
+	now := time.Now()
+	table := PriceTable{
+		SavedAt: now,
+		Digs:    map[int64]int64{1: 3, 4: 5},
+		Measured: map[int64]time.Time{
+			4: now.Add(-2 * time.Hour),
+		},
+	}
+	p := newPriceList(10)
+	p.Seed(table, time.Hour, 0.5)
+
+	experiments := map[int64]bool{}
+	for _, amount := range p.experimentalAmounts {
+		experiments[amount] = true
+	}
+	if experiments[1] {
+		t.Fatal("a confident seeded price is experimented with again")
+	}
+	if !experiments[4] {
+		t.Fatal("an unconfident seeded price is not measured again")
+	}
+}
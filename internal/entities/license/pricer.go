@@ -0,0 +1,29 @@
+package license
+
+import (
+	"fmt"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+// Pricer picks license prices and learns from the purchases committed back
+type Pricer interface {
+	// Next returns the price of the next license, coinsAmount is the most to pay
+	Next(coinsAmount int64) Price
+	// Commit reports the outcome of buying a license at a price from Next
+	Commit(price Price)
+	// Map returns the digs observed per price
+	Map() map[int64]int64
+}
+
+// NewPricer builds the pricer named in the config, the price list if empty
+func NewPricer(cfg config.Config) (Pricer, error) {
+	switch name := cfg.App.License.Pricer; name {
+	case "", "price_list":
+		return NewPriceList(cfg), nil
+	case "thompson":
+		return NewThompsonPricer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown pricer %q", name)
+	}
+}
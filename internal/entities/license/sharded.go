@@ -0,0 +1,274 @@
+package license
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/condwait"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+type shard struct {
+	mu sync.Mutex
+	// licenses with digs left to hand out
+	licenses []license
+	// keeps shards on separate cache lines
+	_ [40]byte
+}
+
+// take hands out a dig of one of the shard's licenses
+func (s *shard) take(p pool) (h Handle, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.licenses)
+	if n == 0 {
+		return Handle{}, false
+	}
+	l := &s.licenses[n-1]
+	h = l.take(p)
+	s.drop(n - 1)
+	return h, true
+}
+
+// takeBatch hands out up to size digs of the shard's license with the
+// most digs left
+func (s *shard) takeBatch(p pool, size int64) (b Batch, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.licenses) == 0 {
+		return Batch{}, false
+	}
+	best := 0
+	for i := range s.licenses {
+		if s.licenses[i].digs > s.licenses[best].digs {
+			best = i
+		}
+	}
+	b = s.licenses[best].takeBatch(p, size)
+	s.drop(best)
+	return b, true
+}
+
+// drop removes the license at i if it has no digs left, s.mu must be held
+func (s *shard) drop(i int) {
+	if s.licenses[i].digs > 0 {
+		return
+	}
+	last := len(s.licenses) - 1
+	s.licenses[i] = s.licenses[last]
+	s.licenses[last] = license{}
+	s.licenses = s.licenses[:last]
+}
+
+// ShardedManager spreads licenses over shards with their own locks. A
+// digger reserves a permit with an atomic counter and takes it from the
+// first shard that has one, the global lock is only taken to wait for
+// permits or license slots and once per issued and finished license.
+type ShardedManager struct {
+	maxLicenses int
+	shards      []shard
+
+	// digs in the shards that are not reserved yet
+	permits int64
+	// round robin cursors for taking and adding
+	nextGet, nextAdd uint32
+
+	mu               sync.Mutex
+	getCond, addCond *sync.Cond
+	// licenses that are issued or being issued
+	active int
+	// every registered id, to reject duplicates
+	ids     map[int64]struct{}
+	deleted int
+	leases  *leases
+}
+
+func NewShardedManager(maxLicenses, shards int, log *logger.Logger) *ShardedManager {
+	m := &ShardedManager{
+		maxLicenses: maxLicenses,
+		shards:      make([]shard, shards),
+		ids:         map[int64]struct{}{},
+		leases:      newLeases(log),
+	}
+	m.getCond = sync.NewCond(&m.mu)
+	m.addCond = sync.NewCond(&m.mu)
+	return m
+}
+
+func (m *ShardedManager) RequestAdd() AddHandle {
+	h, _ := m.RequestAddContext(context.Background())
+	return h
+}
+
+func (m *ShardedManager) RequestAddContext(ctx context.Context) (AddHandle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := condwait.Wait(ctx, m.addCond, func() bool { return m.active < m.maxLicenses }); err != nil {
+		return AddHandle{}, err
+	}
+	m.active++
+	return AddHandle{p: m}, nil
+}
+
+func (m *ShardedManager) fail() {
+	m.mu.Lock()
+	m.active--
+	m.addCond.Signal()
+	m.mu.Unlock()
+}
+
+func (m *ShardedManager) add(id, digs int64) error {
+	if digs < 1 {
+		return errors.New("cannot use 'digs' that are less than 1")
+	}
+	m.mu.Lock()
+	if _, ok := m.ids[id]; ok {
+		m.mu.Unlock()
+		return errors.New("license already registered")
+	}
+	m.ids[id] = struct{}{}
+	m.mu.Unlock()
+
+	l := newLicense(id, digs)
+	m.leases.add(l.lease)
+	m.push(l)
+	return nil
+}
+
+// push puts a license entry in the next shard and makes its digs available
+func (m *ShardedManager) push(l license) {
+	s := &m.shards[atomic.AddUint32(&m.nextAdd, 1)%uint32(len(m.shards))]
+	s.mu.Lock()
+	s.licenses = append(s.licenses, l)
+	s.mu.Unlock()
+
+	// the digs are in the shard before they can be reserved
+	atomic.AddInt64(&m.permits, l.digs)
+	m.mu.Lock()
+	m.getCond.Broadcast()
+	m.mu.Unlock()
+}
+
+// giveBack puts the permits in an entry of their own, a license can be in
+// several shards at once
+func (m *ShardedManager) giveBack(l *lease, permits []int64) {
+	m.push(returnedLicense(l, permits))
+}
+
+// reserve takes a permit, waiting for one if there are none
+func (m *ShardedManager) reserve(ctx context.Context) error {
+	for {
+		p := atomic.LoadInt64(&m.permits)
+		if p > 0 {
+			if atomic.CompareAndSwapInt64(&m.permits, p, p-1) {
+				return nil
+			}
+			continue
+		}
+		m.mu.Lock()
+		err := condwait.Wait(ctx, m.getCond, func() bool { return atomic.LoadInt64(&m.permits) > 0 })
+		m.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (m *ShardedManager) Get() Handle {
+	h, _ := m.GetContext(context.Background())
+	return h
+}
+
+func (m *ShardedManager) GetContext(ctx context.Context) (Handle, error) {
+	if err := m.reserve(ctx); err != nil {
+		return Handle{}, err
+	}
+	n := uint32(len(m.shards))
+	start := atomic.AddUint32(&m.nextGet, 1)
+	// a reserved permit is backed by a dig in some shard
+	for i := uint32(0); ; i++ {
+		if h, ok := m.shards[(start+i)%n].take(m); ok {
+			return h, nil
+		}
+	}
+}
+
+// GetBatch reserves a permit and as many more of the size as are free, the
+// reserved permits one license in a shard can't cover are put back
+func (m *ShardedManager) GetBatch(ctx context.Context, size int) (Batch, error) {
+	if err := m.reserve(ctx); err != nil {
+		return Batch{}, err
+	}
+	want := int64(1)
+	for want < int64(size) {
+		p := atomic.LoadInt64(&m.permits)
+		if p <= 0 {
+			break
+		}
+		k := int64(size) - want
+		if k > p {
+			k = p
+		}
+		if atomic.CompareAndSwapInt64(&m.permits, p, p-k) {
+			want += k
+			break
+		}
+	}
+	n := uint32(len(m.shards))
+	start := atomic.AddUint32(&m.nextGet, 1)
+	for i := uint32(0); ; i++ {
+		b, ok := m.shards[(start+i)%n].takeBatch(m, want)
+		if !ok {
+			continue
+		}
+		if extra := want - int64(b.Len()); extra > 0 {
+			atomic.AddInt64(&m.permits, extra)
+			m.mu.Lock()
+			m.getCond.Broadcast()
+			m.mu.Unlock()
+		}
+		return b, nil
+	}
+}
+
+func (m *ShardedManager) release(l *lease) {
+	m.leases.remove(l)
+	m.mu.Lock()
+	m.active--
+	m.deleted++
+	m.addCond.Signal()
+	m.mu.Unlock()
+}
+
+func (m *ShardedManager) doubleClose(l *lease, n int64) {
+	m.leases.doubleClose(l, n)
+}
+
+func (m *ShardedManager) Audit(maxAge time.Duration) Audit {
+	return m.leases.audit(maxAge)
+}
+
+func (m *ShardedManager) Stats() Stats {
+	m.mu.Lock()
+	s := Stats{
+		MaxLicenses: m.maxLicenses,
+		Active:      m.active,
+		Deleted:     m.deleted,
+	}
+	m.mu.Unlock()
+	s.InUse = m.leases.handedOut()
+	s.DoubleCloses = m.leases.count()
+	for i := range m.shards {
+		sh := &m.shards[i]
+		sh.mu.Lock()
+		s.Available += len(sh.licenses)
+		for _, l := range sh.licenses {
+			s.DigsLeft += l.digs
+		}
+		sh.mu.Unlock()
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package license
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/stats"
+)
+
+// bucket keeps a gamma posterior of the digs per coin at one price
+type bucket struct {
+	price       int64
+	licenses    int64
+	digs, coins float64
+}
+
+// ThompsonPricer treats every price bucket as an arm of a bandit. Digs per
+// coin at a price are modelled as a poisson rate with a gamma prior, Next
+// samples a rate for every affordable bucket and picks the best one, so
+// prices with few observations still get tried while the estimates settle.
+type ThompsonPricer struct {
+	mu      sync.Mutex
+	rnd     *rand.Rand
+	buckets []bucket
+	// prior pseudo-observations of every bucket
+	priorDigs, priorCoins float64
+}
+
+// NewThompsonPricer creates buckets at the powers of two up to the max price
+func NewThompsonPricer(cfg config.Config) *ThompsonPricer {
+	c := cfg.App.License.Thompson
+	p := &ThompsonPricer{
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		priorDigs:  c.PriorDigs,
+		priorCoins: c.PriorCoins,
+	}
+	for price := int64(1); price <= int64(c.MaxPrice); price *= 2 {
+		p.buckets = append(p.buckets, bucket{price: price})
+	}
+	return p
+}
+
+// mean returns the posterior mean of the digs per coin of the bucket
+func (p *ThompsonPricer) mean(b bucket) float64 {
+	return (p.priorDigs + b.digs) / (p.priorCoins + b.coins)
+}
+
+// Next picks the bucket with the best sampled rate. Only a pick that isn't
+// the bucket with the best posterior mean is an experiment, the others have
+// to pay back like any regular purchase.
+func (p *ThompsonPricer) Next(coinsAmount int64) Price {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	best, bestRate := -1, 0.
+	greedy, greedyRate := -1, 0.
+	for i, b := range p.buckets {
+		if b.price > coinsAmount {
+			break
+		}
+		rate := stats.Gamma(p.rnd, p.priorDigs+b.digs, p.priorCoins+b.coins)
+		if best < 0 || rate > bestRate {
+			best, bestRate = i, rate
+		}
+		if mean := p.mean(b); greedy < 0 || mean > greedyRate {
+			greedy, greedyRate = i, mean
+		}
+	}
+	if best < 0 {
+		// nothing is affordable, spend what there is
+		return Price{CoinsAmount: coinsAmount}
+	}
+	return Price{
+		CoinsAmount:  p.buckets[best].price,
+		experimental: best != greedy,
+		sampled:      true,
+	}
+}
+
+func (p *ThompsonPricer) Commit(price Price) {
+	if !price.sampled || price.Failed || price.RealAmount != price.CoinsAmount {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.buckets {
+		b := &p.buckets[i]
+		if b.price == price.CoinsAmount {
+			b.licenses++
+			b.digs += float64(price.Digs)
+			b.coins += float64(price.CoinsAmount)
+			return
+		}
+	}
+}
+
+// Map returns the average digs per license of every tried bucket
+func (p *ThompsonPricer) Map() map[int64]int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m := map[int64]int64{}
+	for _, b := range p.buckets {
+		if b.licenses > 0 {
+			m[b.price] = int64(b.digs) / b.licenses
+		}
+	}
+	return m
+}
@@ -0,0 +1,53 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func newThompsonPricer(maxPrice int) *ThompsonPricer {
+	var cfg config.Config
+	cfg.App.License.Thompson.MaxPrice = maxPrice
+	cfg.App.License.Thompson.PriorDigs = 1
+	cfg.App.License.Thompson.PriorCoins = 1
+	return NewThompsonPricer(cfg)
+}
+
+func TestThompsonPricerExperimentsOnlyWhenExploring(t *testing.T) {
+	p := newThompsonPricer(8)
+	// a lot of evidence that 4 coins bring far more digs per coin
+	for i := 0; i < 1000; i++ {
+		p.Commit(Price{CoinsAmount: 4, RealAmount: 4, Digs: 40, sampled: true})
+		for _, price := range []int64{1, 2, 8} {
+			p.Commit(Price{CoinsAmount: price, RealAmount: price, Digs: 1, sampled: true})
+		}
+	}
+	for i := 0; i < 100; i++ {
+		price := p.Next(100)
+		if price.CoinsAmount != 4 {
+			t.Fatalf("picked %d coins, want 4", price.CoinsAmount)
+		}
+		if price.Experimental() {
+			t.Fatal("the best known price is an experiment")
+		}
+	}
+}
+
+func TestThompsonPricerLearnsFromRegularPurchases(t *testing.T) {
+	p := newThompsonPricer(2)
+	p.Commit(Price{CoinsAmount: 2, RealAmount: 2, Digs: 6, sampled: true})
+	p.Commit(Price{CoinsAmount: 2, RealAmount: 2, Digs: 6, sampled: true, Failed: true})
+	p.Commit(Price{CoinsAmount: 1, RealAmount: 1, Digs: 6})
+	m := p.Map()
+	if len(m) != 1 || m[2] != 6 {
+		t.Fatalf("map = %v, want only 2 coins with 6 digs", m)
+	}
+}
+
+func TestThompsonPricerNothingAffordable(t *testing.T) {
+	p := newThompsonPricer(8)
+	if price := p.Next(0); price.CoinsAmount != 0 || price.Experimental() {
+		t.Fatalf("got %+v for no coins", price)
+	}
+}
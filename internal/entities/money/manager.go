@@ -1,13 +1,161 @@
 package money
 
-//type Manager struct {
-//
-//}
-//
-//func (m *Manager) GetCoinsForLicense() []int32 {
-//
-//}
-//
-//func (m *Manager) AddCoins(coins int64) {
-//
-//}
+import (
+	"sync"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/coin"
+)
+
+type spend struct {
+	time       time.Time
+	amount     int64
+	experiment bool
+}
+
+type Stats struct {
+	Balance   int64 `json:"balance"`
+	Reserve   int64 `json:"reserve"`
+	Available int64 `json:"available"`
+	// coins spent in the last minute
+	Regular    int64 `json:"regular"`
+	Experiment int64 `json:"experiment"`
+	Denied     int64 `json:"denied"`
+	// coins spent so far and the part of them spent on experiments
+	Spent           int64 `json:"spent"`
+	ExperimentSpent int64 `json:"experiment_spent"`
+}
+
+// Manager hands out coins for licenses within a budget: a reserve that is
+// never spent, a cap on the coins spent per minute and a share of the
+// budget for price experiments. The budget is what was spent so far plus
+// what can still be spent, so experiments never take more than their share
+// of the coins over the whole game.
+type Manager struct {
+	mu     sync.Mutex
+	config config.BudgetConfig
+	wallet *coin.Manager
+	// spends of the last minute, oldest first
+	window []spend
+	denied int64
+	// net of refunds
+	spent, experimentSpent int64
+	now                    func() time.Time
+}
+
+func New(cfg config.BudgetConfig, wallet *coin.Manager) *Manager {
+	return &Manager{
+		config: cfg,
+		wallet: wallet,
+		now:    time.Now,
+	}
+}
+
+// prune drops spends older than a minute and returns the remaining
+// regular and experiment spends, m.mu must be held
+func (m *Manager) prune() (regular, experiment int64) {
+	cutoff := m.now().Add(-time.Minute)
+	i := 0
+	for i < len(m.window) && m.window[i].time.Before(cutoff) {
+		i++
+	}
+	m.window = m.window[i:]
+	for _, s := range m.window {
+		if s.experiment {
+			experiment += s.amount
+		} else {
+			regular += s.amount
+		}
+	}
+	return
+}
+
+func (m *Manager) reserve(balance int64) int64 {
+	reserve := int64(m.config.ReserveShare * float64(balance))
+	if reserve < m.config.Reserve {
+		reserve = m.config.Reserve
+	}
+	return reserve
+}
+
+// allowed reports whether the amount fits the budget, m.mu must be held
+func (m *Manager) allowed(amount int64, experiment bool) bool {
+	available := m.wallet.Amount() - m.reserve(m.wallet.Amount())
+	if amount > available {
+		return false
+	}
+	regular, experiments := m.prune()
+	if experiment && float64(m.experimentSpent+amount) > m.config.ExperimentShare*float64(m.spent+available) {
+		return false
+	}
+	limit := m.config.SpendPerMinute
+	if limit <= 0 {
+		return true
+	}
+	if regular+experiments+amount > limit {
+		return false
+	}
+	return !experiment || float64(experiments+amount) <= m.config.ExperimentShare*float64(limit)
+}
+
+// Take pops the coins for a license, ok is false if the budget doesn't
+// allow spending them. Free licenses are always allowed.
+func (m *Manager) Take(amount int64, experiment bool) (coins []uint32, ok bool) {
+	if amount <= 0 {
+		return nil, true
+	}
+	if !m.config.Enabled {
+		return m.wallet.Pop(int(amount)), true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.allowed(amount, experiment) {
+		m.denied++
+		return nil, false
+	}
+	coins = m.wallet.Pop(int(amount))
+	m.record(int64(len(coins)), experiment)
+	return coins, true
+}
+
+// Refund gives back the coins of a failed purchase
+func (m *Manager) Refund(coins []uint32, experiment bool) {
+	m.wallet.Add(coins...)
+	if !m.config.Enabled || len(coins) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.record(-int64(len(coins)), experiment)
+}
+
+// record adds a spend, negative for refunds, m.mu must be held
+func (m *Manager) record(amount int64, experiment bool) {
+	m.window = append(m.window, spend{time: m.now(), amount: amount, experiment: experiment})
+	m.spent += amount
+	if experiment {
+		m.experimentSpent += amount
+	}
+}
+
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	balance := m.wallet.Amount()
+	regular, experiment := m.prune()
+	s := Stats{
+		Balance:    balance,
+		Regular:    regular,
+		Experiment: experiment,
+		Denied:     m.denied,
+
+		Spent:           m.spent,
+		ExperimentSpent: m.experimentSpent,
+	}
+	if m.config.Enabled {
+		s.Reserve = m.reserve(balance)
+	}
+	s.Available = balance - s.Reserve
+	return s
+}
@@ -0,0 +1,96 @@
+package money
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/coin"
+)
+
+func newManager(cfg config.BudgetConfig, coins int) (*Manager, *time.Time) {
+	wallet := coin.NewManager()
+	for i := 0; i < coins; i++ {
+		wallet.Add(uint32(i))
+	}
+	m := New(cfg, wallet)
+	now := time.Unix(0, 0)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func take(t *testing.T, m *Manager, amount int64, experiment, want bool) []uint32 {
+	t.Helper()
+	coins, ok := m.Take(amount, experiment)
+	if ok != want {
+		t.Fatalf("Take(%d, experiment=%v) = %v, want %v (stats %+v)", amount, experiment, ok, want, m.Stats())
+	}
+	if ok && int64(len(coins)) != amount {
+		t.Fatalf("took %d coins, want %d", len(coins), amount)
+	}
+	return coins
+}
+
+func TestExperimentShareOfTheBudget(t *testing.T) {
+	m, _ := newManager(config.BudgetConfig{Enabled: true, Reserve: 10, ExperimentShare: 0.2}, 100)
+	// 90 coins can be spent, experiments may use 18 of them
+	first := take(t, m, 10, true, true)
+	take(t, m, 10, true, false)
+	take(t, m, 50, false, true)
+	// spending regular coins doesn't make room for experiments
+	take(t, m, 5, true, true)
+	take(t, m, 5, true, false)
+
+	m.Refund(first, true)
+	take(t, m, 10, true, true)
+	s := m.Stats()
+	if s.Spent != 65 || s.ExperimentSpent != 15 || s.Denied != 2 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	m, _ := newManager(config.BudgetConfig{Enabled: true, Reserve: 10, ReserveShare: 0.5}, 100)
+	take(t, m, 51, false, false)
+	take(t, m, 50, false, true)
+	// half of what is left is kept
+	take(t, m, 26, false, false)
+	take(t, m, 25, false, true)
+	if s := m.Stats(); s.Balance != 25 || s.Reserve != 12 || s.Available != 13 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestSpendPerMinute(t *testing.T) {
+	m, now := newManager(config.BudgetConfig{Enabled: true, SpendPerMinute: 20, ExperimentShare: 0.5}, 100)
+	take(t, m, 15, false, true)
+	take(t, m, 10, false, false)
+	// experiments get half of the per minute cap
+	take(t, m, 5, true, true)
+	*now = now.Add(time.Minute + time.Second)
+	take(t, m, 11, true, false)
+	take(t, m, 10, true, true)
+	take(t, m, 10, false, true)
+	if s := m.Stats(); s.Regular != 10 || s.Experiment != 10 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestRefundOpensTheWindow(t *testing.T) {
+	m, _ := newManager(config.BudgetConfig{Enabled: true, SpendPerMinute: 10}, 100)
+	coins := take(t, m, 10, false, true)
+	take(t, m, 1, false, false)
+	m.Refund(coins, false)
+	take(t, m, 10, false, true)
+	if s := m.Stats(); s.Balance != 90 || s.Spent != 10 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestDisabledBudget(t *testing.T) {
+	m, _ := newManager(config.BudgetConfig{Reserve: 1000}, 10)
+	take(t, m, 10, true, true)
+	if coins, ok := m.Take(0, false); !ok || coins != nil {
+		t.Fatal("a free license was refused")
+	}
+}
@@ -0,0 +1,52 @@
+package price_controller
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// Controller picks the most to pay for a license from the coins earned
+type Controller interface {
+	// GetPrice returns the most to pay for a license, 0 means free licenses
+	GetPrice() int64
+	AddCoins(amount int64)
+	DeleteCoins(amount int64)
+	// AddFreeDigs records the digs of a free license, they don't move the
+	// price since they cost no coins
+	AddFreeDigs(digs int64)
+	// FreeDigs returns the digs of free licenses so far
+	FreeDigs() int64
+	// Run adjusts the price every interval until Stop is called
+	Run(interval time.Duration)
+	Stop()
+}
+
+// freeDigs counts the digs of free licenses apart from the paid ones
+type freeDigs struct {
+	digs int64
+}
+
+func (f *freeDigs) AddFreeDigs(digs int64) {
+	atomic.AddInt64(&f.digs, digs)
+}
+
+func (f *freeDigs) FreeDigs() int64 {
+	return atomic.LoadInt64(&f.digs)
+}
+
+// FromConfig builds the controller named in the config, the legacy one if empty
+func FromConfig(cfg config.PriceControllerConfig, log *logger.Logger, j *journal.Journal) (Controller, error) {
+	switch cfg.Type {
+	case "", "legacy":
+		return New(log, j), nil
+	case "pid":
+		return NewPID(cfg.PID, RealClock(), log, j), nil
+	default:
+		return nil, fmt.Errorf("unknown price controller %q", cfg.Type)
+	}
+}
@@ -0,0 +1,159 @@
+package price_controller
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// PIDController climbs to the price with the most coins per second. Every
+// window it measures the coin rate at the current price, estimates the
+// slope of the rate over the price from the previous window and moves the
+// price by a PID term of that slope, so it settles where the slope is zero.
+type PIDController struct {
+	freeDigs
+	config  config.PIDConfig
+	clock   Clock
+	log     *logger.Logger
+	journal *journal.Journal
+
+	currentPrice int64
+	totalCoins   int64
+
+	// rate samples of the current window
+	sum     float64
+	samples int
+
+	hasPrev   bool
+	prevPrice int64
+	prevCPS   float64
+	prevSlope float64
+	integral  float64
+	// direction of the last step, used to keep probing on a flat slope
+	direction int64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (p *PIDController) GetPrice() int64 {
+	return atomic.LoadInt64(&p.currentPrice)
+}
+
+func (p *PIDController) AddCoins(amount int64) {
+	atomic.AddInt64(&p.totalCoins, amount)
+}
+
+func (p *PIDController) DeleteCoins(amount int64) {
+	atomic.AddInt64(&p.totalCoins, -amount)
+}
+
+func (p *PIDController) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+func (p *PIDController) Run(interval time.Duration) {
+	t := p.clock.NewTicker(interval)
+	defer t.Stop()
+	last := p.clock.Now()
+	lastCoins := atomic.LoadInt64(&p.totalCoins)
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-t.C():
+			elapsed := now.Sub(last).Seconds()
+			coins := atomic.LoadInt64(&p.totalCoins)
+			if elapsed <= 0 {
+				continue
+			}
+			p.observe(float64(coins-lastCoins) / elapsed)
+			last, lastCoins = now, coins
+		}
+	}
+}
+
+// observe adds a rate sample and moves the price once the window is full
+func (p *PIDController) observe(cps float64) {
+	p.sum += cps
+	p.samples++
+	if p.samples < p.config.Window {
+		return
+	}
+	avg := p.sum / float64(p.samples)
+	p.sum, p.samples = 0, 0
+	p.update(avg)
+}
+
+func (p *PIDController) update(cps float64) {
+	price := p.GetPrice()
+	var u float64
+	if p.hasPrev && price != p.prevPrice {
+		slope := (cps - p.prevCPS) / float64(price-p.prevPrice)
+		p.integral = math.Max(-p.config.MaxIntegral, math.Min(p.config.MaxIntegral, p.integral+slope))
+		u = p.config.Kp*slope + p.config.Ki*p.integral + p.config.Kd*(slope-p.prevSlope)
+		p.prevSlope = slope
+	}
+	p.hasPrev, p.prevPrice, p.prevCPS = true, price, cps
+
+	next := price + p.step(u)
+	if next < p.config.MinPrice {
+		next = p.config.MinPrice
+	}
+	if next > p.config.MaxPrice {
+		next = p.config.MaxPrice
+	}
+	if next == price {
+		// stuck at a bound, probe the other way
+		p.direction = -p.direction
+		return
+	}
+	p.log.Debug("price changed",
+		logger.F("from", price),
+		logger.F("to", next),
+		logger.F("cps", cps),
+		logger.F("control", u),
+	)
+	p.journal.PriceChanged(price, next)
+	atomic.StoreInt64(&p.currentPrice, next)
+}
+
+// step rounds the control to a step between MinStep and MaxStep
+func (p *PIDController) step(u float64) int64 {
+	s := int64(math.Round(u))
+	if s == 0 {
+		s = p.direction
+	}
+	sign := int64(1)
+	if s < 0 {
+		sign, s = -1, -s
+	}
+	if s < p.config.MinStep {
+		s = p.config.MinStep
+	}
+	if s > p.config.MaxStep {
+		s = p.config.MaxStep
+	}
+	p.direction = sign
+	return sign * s
+}
+
+func NewPID(cfg config.PIDConfig, clock Clock, log *logger.Logger, j *journal.Journal) *PIDController {
+	if cfg.Window < 1 {
+		cfg.Window = 1
+	}
+	return &PIDController{
+		config:       cfg,
+		clock:        clock,
+		log:          log,
+		journal:      j,
+		currentPrice: cfg.StartPrice,
+		direction:    1,
+		stop:         make(chan struct{}),
+	}
+}
@@ -0,0 +1,150 @@
+package price_controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+func testPIDConfig() config.PIDConfig {
+	return config.PIDConfig{
+		Kp:          1,
+		MaxIntegral: 3,
+		StartPrice:  10,
+		MinPrice:    1,
+		MaxPrice:    100,
+		MinStep:     1,
+		MaxStep:     5,
+		Window:      1,
+	}
+}
+
+func newTestPID(cfg config.PIDConfig, clock Clock) *PIDController {
+	return NewPID(cfg, clock, logger.Nop(), nil)
+}
+
+func TestPIDStepLimits(t *testing.T) {
+	p := newTestPID(testPIDConfig(), RealClock())
+	cases := []struct {
+		u    float64
+		want int64
+	}{
+		{u: 100, want: 5},
+		{u: 3, want: 3},
+		{u: 0.2, want: 1},
+		// no control keeps going the last way
+		{u: 0, want: 1},
+		{u: -100, want: -5},
+		{u: 0, want: -1},
+		{u: -0.4, want: -1},
+	}
+	for _, c := range cases {
+		if got := p.step(c.u); got != c.want {
+			t.Errorf("step(%v) = %d, want %d", c.u, got, c.want)
+		}
+	}
+}
+
+func TestPIDClampsPrice(t *testing.T) {
+	cfg := testPIDConfig()
+	cfg.StartPrice = cfg.MaxPrice
+	p := newTestPID(cfg, RealClock())
+	p.update(10)
+	if got := p.GetPrice(); got != cfg.MaxPrice {
+		t.Fatalf("price = %d above the max %d", got, cfg.MaxPrice)
+	}
+	// stuck at the bound it probes the other way
+	p.update(10)
+	if got := p.GetPrice(); got != cfg.MaxPrice-cfg.MinStep {
+		t.Fatalf("price = %d, want %d after turning around", got, cfg.MaxPrice-cfg.MinStep)
+	}
+
+	cfg.StartPrice = cfg.MinPrice
+	p = newTestPID(cfg, RealClock())
+	p.direction = -1
+	p.update(10)
+	if got := p.GetPrice(); got != cfg.MinPrice {
+		t.Fatalf("price = %d below the min %d", got, cfg.MinPrice)
+	}
+	p.update(10)
+	if got := p.GetPrice(); got != cfg.MinPrice+cfg.MinStep {
+		t.Fatalf("price = %d, want %d after turning around", got, cfg.MinPrice+cfg.MinStep)
+	}
+}
+
+func TestPIDIntegralWindup(t *testing.T) {
+	cfg := testPIDConfig()
+	cfg.Kp = 0
+	cfg.Ki = 1
+	cfg.MaxPrice = 1000
+	p := newTestPID(cfg, RealClock())
+	// the rate keeps rising with the price, every slope is positive
+	cps := 0.
+	for i := 0; i < 20; i++ {
+		cps += 100
+		p.update(cps)
+		if p.integral > cfg.MaxIntegral {
+			t.Fatalf("integral %v above %v", p.integral, cfg.MaxIntegral)
+		}
+	}
+	if p.integral != cfg.MaxIntegral {
+		t.Fatalf("integral = %v, want it held at %v", p.integral, cfg.MaxIntegral)
+	}
+	// a bounded integral turns around as soon as the slope does
+	price := p.GetPrice()
+	p.update(0)
+	p.update(-1000)
+	if p.GetPrice() >= price+cfg.MaxStep {
+		t.Fatalf("price kept climbing to %d after the slope turned", p.GetPrice())
+	}
+}
+
+func TestPIDRunAndStop(t *testing.T) {
+	cfg := testPIDConfig()
+	cfg.Window = 2
+	clock := newFakeClock()
+	p := newTestPID(cfg, clock)
+	done := make(chan struct{})
+	go func() {
+		p.Run(time.Second)
+		close(done)
+	}()
+	for clock.tickerCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	tick := func(coins int64) {
+		p.AddCoins(coins)
+		clock.Advance(time.Second)
+	}
+	// a full window at 10 coins per second probes up by the min step
+	tick(10)
+	tick(10)
+	// 20 coins per second at 11: the slope of 10 is capped by the max step
+	tick(20)
+	tick(20)
+	// half a window doesn't move the price
+	tick(40)
+
+	p.Stop()
+	p.Stop()
+	<-done
+	if got := p.GetPrice(); got != 16 {
+		t.Fatalf("price = %d, want 16", got)
+	}
+	if !clock.stopped() {
+		t.Fatal("ticker was not stopped")
+	}
+}
+
+func TestFreeDigs(t *testing.T) {
+	for _, c := range []Controller{newTestPID(testPIDConfig(), RealClock()), New(logger.Nop(), nil)} {
+		c.AddFreeDigs(3)
+		c.AddFreeDigs(4)
+		if got := c.FreeDigs(); got != 7 {
+			t.Fatalf("%T: free digs = %d, want 7", c, got)
+		}
+	}
+}
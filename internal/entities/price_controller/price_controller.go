@@ -1,8 +1,9 @@
 package price_controller
 
 import (
-	"fmt"
-	"log"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/journal"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -10,11 +11,20 @@ import (
 
 
 type PriceController struct {
+	freeDigs
 	currentPrice int64
 	priceChan chan float64
 	startCoef float64
 	maxDelta float64
 	totalCoins int64
+	log *logger.Logger
+	journal *journal.Journal
+	stop chan struct{}
+	stopOnce sync.Once
+}
+
+func (p *PriceController) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
 }
 
 func (p *PriceController) DeleteCoins(amount int64) {
@@ -27,6 +37,7 @@ func (p *PriceController) AddCoins(amount int64) {
 
 func (p *PriceController) runBenchmark(interval time.Duration) {
 	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
 	prevCoins := 0.
 
@@ -38,6 +49,8 @@ func (p *PriceController) runBenchmark(interval time.Duration) {
 
 	for {
 		select {
+		case <-p.stop:
+			return
 		case <-ticker.C:
 			now := time.Now().UnixNano()
 			timeDelta := now - prevTime
@@ -56,9 +69,13 @@ func (p *PriceController) runBenchmark(interval time.Duration) {
 }
 
 func (p *PriceController) push(coinsPerSecond float64) {
-	p.priceChan <- coinsPerSecond
+	select {
+	case p.priceChan <- coinsPerSecond:
+	case <-p.stop:
+	}
 }
 
+// GetPrice returns the most to pay for a license, 0 means free licenses
 func (p *PriceController) GetPrice() int64 {
 	return atomic.LoadInt64(&p.currentPrice)
 }
@@ -82,11 +99,21 @@ func (p *PriceController) Run(interval time.Duration) {
 
 	currentCoef := 1.
 
-	for cps := range p.priceChan {
+	for {
+		var cps float64
+		select {
+		case cps = <-p.priceChan:
+		case <-p.stop:
+			return
+		}
 		toBeIncreased, toBeDecreased := false, false
 
 		if counter == 19 {
-			fmt.Println(maxCps/currentAVGCPS, "- ratio", maxCps, currentAVGCPS)
+			p.log.Debug("cps ratio",
+				logger.F("ratio", maxCps/currentAVGCPS),
+				logger.F("max_cps", maxCps),
+				logger.F("current_cps", currentAVGCPS),
+			)
 		}
 
 		if maxCps/currentAVGCPS >= 1.05 && counter > counterBreaker/5 || counter > counterBreaker/6 && currentAVGCPS <= 0 {
@@ -106,13 +133,13 @@ func (p *PriceController) Run(interval time.Duration) {
 				maxCpsPrice /= 2
 				maxCps = 0.
 			}
-			log.Printf("%d => %d (%d)", currentPrice, newPrice, currentPrice - newPrice)
+			p.log.Info("price decreased", logger.F("from", currentPrice), logger.F("to", newPrice))
 			currentPrice = newPrice
 			currentAVGCPS = 0
 			currentCoef = 1.
 			counter = 0
 		} else if toBeIncreased {
-			log.Printf("%d => %d (%d)", currentPrice, currentPrice+1, 1)
+			p.log.Info("price increased", logger.F("from", currentPrice), logger.F("to", currentPrice+int64(currentCoef)))
 			currentPrice += int64(currentCoef)
 			currentCoef *= p.startCoef
 			currentAVGCPS = 0
@@ -121,15 +148,26 @@ func (p *PriceController) Run(interval time.Duration) {
 			currentAVGCPS = (currentAVGCPS * counter + cps)/(counter+1)
 			counter++
 		}
+		if prev := p.GetPrice(); prev != currentPrice {
+			p.journal.PriceChanged(prev, currentPrice)
+			if currentPrice == 0 {
+				p.log.Info("switched to free licenses", logger.F("from", prev))
+			} else if prev == 0 {
+				p.log.Info("switched to paid licenses", logger.F("to", currentPrice))
+			}
+		}
 		p.savePrice(currentPrice)
 	}
 }
 
-func New() *PriceController {
+func New(log *logger.Logger, j *journal.Journal) *PriceController {
 	return &PriceController{
 		currentPrice: 0,
 		priceChan:    make(chan float64),
 		startCoef:    1.0003,
 		totalCoins:   0,
+		log:          log,
+		journal:      j,
+		stop:         make(chan struct{}),
 	}
 }
\ No newline at end of file
@@ -1,9 +1,15 @@
+//go:generate go run ../gen -spec ../spec/swagger.yaml -models ../models -api .
+
 package api
 
 import (
+	"context"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/http/models"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/http/poller"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/mertics"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/ratelimit"
 	"github.com/valyala/fasthttp"
 	"time"
 )
@@ -13,6 +19,11 @@ func validateResponse(data interface{}, err error) (interface{}, error, bool) {
 		return data, nil, true
 	}
 
+	switch err {
+	case ratelimit.DeadlineReachedErr:
+		return nil, err, false
+	}
+
 	switch err.(type) {
 	case NotStatedErr:
 		return nil, err, false
@@ -21,26 +32,53 @@ func validateResponse(data interface{}, err error) (interface{}, error, bool) {
 	return data, err, true
 }
 
+type limiter struct {
+	bucket  *ratelimit.Bucket
+	waitKey string
+}
+
 type API struct {
 	pollers struct {
 		dig, licenses, cash, explore, healthCheck *poller.Poller
 	}
-	client *client
+	limiters struct {
+		dig, licenses, cash, explore, healthCheck limiter
+	}
+	ctx     context.Context
+	metrics *mertics.Metrics
+	client  *client
 }
 
-// returns a treasure list and an error
-func (api *API) Dig(data models.Dig) ([]string, error) {
-	res, err := api.pollers.dig.Do(func(dl time.Time) (interface{}, error, bool) {
-		return validateResponse(api.client.Dig(dl, data))
-	})
-	if result, ok := res.([]string); ok {
-		return result, err
+// SetContext sets the context that interrupts waiting for rate limits
+func (api *API) SetContext(ctx context.Context) {
+	api.ctx = ctx
+}
+
+// wait blocks until the endpoint's rate limit allows another attempt
+func (api *API) wait(l *limiter, deadline time.Time) error {
+	waited, err := l.bucket.Wait(api.ctx, deadline)
+	if waited > 0 {
+		api.metrics.AddAverage(l.waitKey, float64(waited))
 	}
-	return nil, err
+	return err
+}
+
+// decodes found treasures into the provided list, see models.AcquireTreasureList
+func (api *API) Dig(data models.Dig, treasures *models.TreasureList) error {
+	_, err := api.pollers.dig.Do(func(dl time.Time) (interface{}, error, bool) {
+		if err := api.wait(&api.limiters.dig, dl); err != nil {
+			return validateResponse(nil, err)
+		}
+		return validateResponse(nil, api.client.Dig(dl, data, treasures))
+	})
+	return err
 }
 
 func (api *API) IssueLicenses(data []uint32) (models.License, error) {
 	res, err := api.pollers.licenses.Do(func(dl time.Time) (interface{}, error, bool) {
+		if err := api.wait(&api.limiters.licenses, dl); err != nil {
+			return validateResponse(nil, err)
+		}
 		return validateResponse(api.client.IssueLicenses(dl, data))
 	})
 	if result, ok := res.(models.License); ok {
@@ -51,6 +89,9 @@ func (api *API) IssueLicenses(data []uint32) (models.License, error) {
 
 func (api *API) ListLicenses() ([]models.License, error) {
 	res, err := api.pollers.licenses.Do(func(dl time.Time) (interface{}, error, bool) {
+		if err := api.wait(&api.limiters.licenses, dl); err != nil {
+			return validateResponse(nil, err)
+		}
 		return validateResponse(api.client.ListLicenses(dl))
 	})
 	if result, ok := res.([]models.License); ok {
@@ -59,18 +100,22 @@ func (api *API) ListLicenses() ([]models.License, error) {
 	return nil, err
 }
 
-func (api *API) Cash(data string) ([]uint32, error) {
-	res, err := api.pollers.cash.Do(func(dl time.Time) (interface{}, error, bool) {
-		return validateResponse(api.client.Cash(dl, data))
+// decodes received coins into the provided wallet, see models.AcquireWallet
+func (api *API) Cash(data string, coins *models.Wallet) error {
+	_, err := api.pollers.cash.Do(func(dl time.Time) (interface{}, error, bool) {
+		if err := api.wait(&api.limiters.cash, dl); err != nil {
+			return validateResponse(nil, err)
+		}
+		return validateResponse(nil, api.client.Cash(dl, data, coins))
 	})
-	if result, ok := res.([]uint32); ok {
-		return result, err
-	}
-	return nil, err
+	return err
 }
 
 func (api *API) Explore(data models.Area) (models.Report, error) {
 	res, err := api.pollers.explore.Do(func(dl time.Time) (interface{}, error, bool) {
+		if err := api.wait(&api.limiters.explore, dl); err != nil {
+			return validateResponse(nil, err)
+		}
 		return validateResponse(api.client.Explore(dl, data))
 	})
 	if result, ok := res.(models.Report); ok {
@@ -81,6 +126,9 @@ func (api *API) Explore(data models.Area) (models.Report, error) {
 
 func (api *API) ExploreDeadline(deadline time.Time, data models.Area) (models.Report, error) {
 	res, err := api.pollers.explore.DoDeadline(deadline, func(dl time.Time) (interface{}, error, bool) {
+		if err := api.wait(&api.limiters.explore, dl); err != nil {
+			return validateResponse(nil, err)
+		}
 		return validateResponse(api.client.Explore(dl, data))
 	})
 	if result, ok := res.(models.Report); ok {
@@ -91,6 +139,9 @@ func (api *API) ExploreDeadline(deadline time.Time, data models.Area) (models.Re
 
 func (api *API) HealthCheck() error {
 	_, err := api.pollers.healthCheck.Do(func(dl time.Time) (interface{}, error, bool) {
+		if e := api.wait(&api.limiters.healthCheck, dl); e != nil {
+			return validateResponse(nil, e)
+		}
 		if e := api.client.HealthCheck(dl); e != nil {
 			return nil, e, false
 		}
@@ -99,16 +150,36 @@ func (api *API) HealthCheck() error {
 	return err
 }
 
-func (api *API) initPollers(cfg config.Config) {
-	api.pollers.licenses = poller.FromConfig(cfg.Api.IssueLicensePoller)
-	api.pollers.dig = poller.FromConfig(cfg.Api.DigPoller)
-	api.pollers.healthCheck = poller.FromConfig(cfg.Api.HealthCheckPoller)
-	api.pollers.cash = poller.FromConfig(cfg.Api.CashPoller)
-	api.pollers.explore = poller.FromConfig(cfg.Api.ExplorePoller)
+func (api *API) initPollers(cfg config.Config, log *logger.Logger) {
+	newPoller := func(endpoint string, cfg config.PollerConfig) *poller.Poller {
+		return poller.FromConfig(cfg, log.With(logger.F("endpoint", endpoint)))
+	}
+	api.pollers.licenses = newPoller("licenses", cfg.Api.IssueLicensePoller)
+	api.pollers.dig = newPoller("dig", cfg.Api.DigPoller)
+	api.pollers.healthCheck = newPoller("health_check", cfg.Api.HealthCheckPoller)
+	api.pollers.cash = newPoller("cash", cfg.Api.CashPoller)
+	api.pollers.explore = newPoller("explore", cfg.Api.ExplorePoller)
+}
+
+func (api *API) initLimiters(cfg config.Config) {
+	newLimiter := func(name string, cfg config.RateLimitConfig) limiter {
+		return limiter{
+			bucket:  ratelimit.New(cfg.Rate, cfg.Burst),
+			waitKey: name + "_rate_limit_wait",
+		}
+	}
+	api.limiters.licenses = newLimiter("licenses", cfg.Api.RateLimits.Licenses)
+	api.limiters.dig = newLimiter("dig", cfg.Api.RateLimits.Dig)
+	api.limiters.healthCheck = newLimiter("health_check", cfg.Api.RateLimits.HealthCheck)
+	api.limiters.cash = newLimiter("cash", cfg.Api.RateLimits.Cash)
+	api.limiters.explore = newLimiter("explore", cfg.Api.RateLimits.Explore)
 }
 
-func New(config config.Config) *API {
-	api := &API{}
+func New(config config.Config, metrics *mertics.Metrics, log *logger.Logger) *API {
+	api := &API{
+		ctx:     context.Background(),
+		metrics: metrics,
+	}
 
 	api.client = newClient(config.BaseURL, &fasthttp.Client{
 		MaxConnsPerHost:               50000,
@@ -120,7 +191,8 @@ func New(config config.Config) *API {
 		MaxConnWaitTimeout:            time.Second * 30,
 	})
 
-	api.initPollers(config)
+	api.initPollers(config, log)
+	api.initLimiters(config)
 
 	return api
 }
\ No newline at end of file
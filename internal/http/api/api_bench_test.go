@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/http/models"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/mertics"
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	cannedTreasures = []byte(`["tr4s0re-1d-0"]`)
+	cannedCoins     = []byte(`[101,102,103,104,105,106,107,108,109,110]`)
+	cannedLicense   = []byte(`{"id":7,"digAllowed":5,"digUsed":0}`)
+	cannedReport    = []byte(`{"area":{"posX":1,"posY":1,"sizeX":2,"sizeY":3},"amount":4}`)
+	// cells at posX=0 have no treasures
+	emptyCell = []byte(`"posX":0,`)
+)
+
+func cannedHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+	switch string(ctx.Path()) {
+	case "/dig":
+		if bytes.Contains(ctx.PostBody(), emptyCell) {
+			ctx.SetStatusCode(404)
+			return
+		}
+		ctx.SetBody(cannedTreasures)
+	case "/cash":
+		ctx.SetBody(cannedCoins)
+	case "/licenses":
+		ctx.SetBody(cannedLicense)
+	case "/explore":
+		ctx.SetBody(cannedReport)
+	default:
+		ctx.SetStatusCode(404)
+	}
+}
+
+// newBenchAPI starts an in-process server with canned responses
+func newBenchAPI(b *testing.B) *API {
+	return newFakeAPI(b, cannedHandler)
+}
+
+// newFakeAPI starts an in-process server answering with handler
+func newFakeAPI(tb testing.TB, handler fasthttp.RequestHandler) *API {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { ln.Close() })
+	go fasthttp.Serve(ln, handler)
+
+	var cfg config.Config
+	pc := config.PollerConfig{TimeOut: "5s", Interval: "0", MaxIters: 1}
+	cfg.Api.DigPoller = pc
+	cfg.Api.CashPoller = pc
+	cfg.Api.ExplorePoller = pc
+	cfg.Api.HealthCheckPoller = pc
+	cfg.Api.IssueLicensePoller = pc
+	cfg.BaseURL = "http://" + ln.Addr().String()
+	return New(cfg, mertics.New(false), logger.Nop())
+}
+
+// BenchmarkAPI measures every endpoint. fasthttp's DoDeadline accounts for
+// two allocations per call, found treasures for one more each (their ids
+// outlive the response), the decoders themselves don't allocate.
+func BenchmarkAPI(b *testing.B) {
+	a := newBenchAPI(b)
+
+	b.Run("dig/found", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l := models.AcquireTreasureList()
+			if err := a.Dig(models.Dig{Depth: 1, LicenseID: 7, PosX: 10, PosY: 10}, l); err != nil {
+				b.Fatal(err)
+			}
+			models.ReleaseTreasureList(l)
+		}
+	})
+
+	b.Run("dig/empty", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l := models.AcquireTreasureList()
+			if _, ok := a.Dig(models.Dig{Depth: 1, LicenseID: 7, PosX: 0, PosY: 10}, l).(TreasureNotFoundErr); !ok {
+				b.Fatal("expected no treasures")
+			}
+			models.ReleaseTreasureList(l)
+		}
+	})
+
+	b.Run("cash", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w := models.AcquireWallet()
+			if err := a.Cash("tr4s0re-1d-0", w); err != nil {
+				b.Fatal(err)
+			}
+			models.ReleaseWallet(w)
+		}
+	})
+
+	wallet := []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	b.Run("issue_license", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := a.IssueLicenses(wallet); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("explore", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := a.Explore(models.Area{PosX: 1, PosY: 1, SizeX: 2, SizeY: 3}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
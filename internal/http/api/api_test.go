@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/http/models"
+	"github.com/valyala/fasthttp"
+)
+
+func licensesHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+	if string(ctx.Path()) != "/licenses" || !ctx.IsGet() {
+		ctx.SetStatusCode(404)
+		return
+	}
+	ctx.SetBodyString(`[{"id":7,"digAllowed":5,"digUsed":2},{"id":8,"digAllowed":3,"digUsed":0}]`)
+}
+
+func TestListLicenses(t *testing.T) {
+	a := newFakeAPI(t, licensesHandler)
+	licenses, err := a.ListLicenses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLicenses(t, licenses)
+}
+
+func TestListLicensesRateLimited(t *testing.T) {
+	a := newFakeAPI(t, licensesHandler)
+	var cfg config.Config
+	cfg.Api.RateLimits.Licenses = config.RateLimitConfig{Rate: 100, Burst: 1}
+	a.initLimiters(cfg)
+	// the second call waits for a token
+	for i := 0; i < 2; i++ {
+		licenses, err := a.ListLicenses()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkLicenses(t, licenses)
+	}
+}
+
+func checkLicenses(t *testing.T, licenses []models.License) {
+	t.Helper()
+	want := []models.License{{ID: 7, DigAllowed: 5, DigUsed: 2}, {ID: 8, DigAllowed: 3}}
+	if len(licenses) != len(want) {
+		t.Fatalf("licenses = %+v, want %+v", licenses, want)
+	}
+	for i := range want {
+		if licenses[i] != want[i] {
+			t.Fatalf("licenses = %+v, want %+v", licenses, want)
+		}
+	}
+}
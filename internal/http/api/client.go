@@ -3,7 +3,8 @@ package api
 import (
 	"fmt"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/http/models"
-	jsoniter "github.com/json-iterator/go"
+	"github.com/mailru/easyjson/buffer"
+	"github.com/mailru/easyjson/jwriter"
 	"github.com/valyala/fasthttp"
 	"time"
 )
@@ -21,57 +22,45 @@ type client struct {
 }
 
 
-// returns a treasure list and an error
-func (c *client) Dig(deadline time.Time, data models.Dig) ([]string, error) {
-	bts, err := data.MarshalJSON()
-
-	if err != nil {
-		return nil, err
-	}
-
+// decodes found treasures into the provided list
+func (c *client) Dig(deadline time.Time, data models.Dig, treasures *models.TreasureList) error {
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
 	res := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(res)
 
+	w := bodyWriter(req)
+	data.MarshalEasyJSON(&w)
+	if err := setBody(req, &w); err != nil {
+		return err
+	}
+
 	req.SetRequestURI(c.urls.dig)
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
-	req.SetBody(bts)
 
-	err = c.httpClient.DoDeadline(req, res, deadline)
+	err := c.httpClient.DoDeadline(req, res, deadline)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	switch res.StatusCode() {
 	case 200:
-		var treasures []string
-		if err := jsoniter.Unmarshal(res.Body(), &treasures); err != nil {
-			return nil, err
-		}
-		return treasures, nil
+		return treasures.UnmarshalJSON(res.Body())
 	case 403:
-		return nil, NoSuchLicenseErr{}
+		return NoSuchLicenseErr{}
 	case 404:
-		return nil, TreasureNotFoundErr{}
+		return TreasureNotFoundErr{}
 	case 422:
 		var response models.Error
-		if err := jsoniter.Unmarshal(res.Body(), &response); err != nil {
-			return nil, err
-		}
-		switch response.Code {
-		case 1000:
-			return nil, WrongCoordinatesErr{}
-		case 1001:
-			return nil, WrongDepthErr{}
-		default:
-			return nil, NotStatedErr{}
+		if err := response.UnmarshalJSON(res.Body()); err != nil {
+			return err
 		}
+		return specError(res.StatusCode(), response.Code)
 	default:
-		return nil, NotStatedErr{}
+		return NotStatedErr{}
 	}
 }
 
@@ -79,24 +68,25 @@ func (c *client) IssueLicenses(deadline time.Time, data []uint32) (models.Licens
 
 	var license models.License
 
-	bts, err := jsoniter.Marshal(data)
-
-	if err != nil {
-		return license, err
-	}
-
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
 	res := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(res)
 
+	w := bodyWriter(req)
+	// an empty wallet must be sent as [] to get a free license
+	w.Flags |= jwriter.NilSliceAsEmpty
+	models.Wallet(data).MarshalEasyJSON(&w)
+	if err := setBody(req, &w); err != nil {
+		return license, err
+	}
+
 	req.SetRequestURI(c.urls.licenses)
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
-	req.SetBody(bts)
 
-	err = c.httpClient.DoDeadline(req, res, deadline)
+	err := c.httpClient.DoDeadline(req, res, deadline)
 
 	if err != nil {
 		return license, err
@@ -104,12 +94,16 @@ func (c *client) IssueLicenses(deadline time.Time, data []uint32) (models.Licens
 
 	switch res.StatusCode() {
 	case 200:
-		if err := jsoniter.Unmarshal(res.Body(), &license); err != nil {
+		if err := license.UnmarshalJSON(res.Body()); err != nil {
 			return license, err
 		}
 		return license, nil
 	case 409:
-		return license, NoMoreLicensesAllowedErr{}
+		var response models.Error
+		if err := response.UnmarshalJSON(res.Body()); err != nil {
+			return license, err
+		}
+		return license, specError(res.StatusCode(), response.Code)
 	default:
 		return license, NotStatedErr{}
 	}
@@ -134,8 +128,8 @@ func (c *client) ListLicenses(deadline time.Time) ([]models.License, error) {
 
 	switch res.StatusCode() {
 	case 200:
-		var licenseList []models.License
-		if err := jsoniter.Unmarshal(res.Body(), &licenseList); err != nil {
+		var licenseList models.LicenseList
+		if err := licenseList.UnmarshalJSON(res.Body()); err != nil {
 			return nil, err
 		}
 		return licenseList, nil
@@ -144,40 +138,41 @@ func (c *client) ListLicenses(deadline time.Time) ([]models.License, error) {
 	}
 }
 
-func (c *client) Cash(deadline time.Time, data string) ([]uint32, error) {
-	bts, err := jsoniter.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
+// decodes received coins into the provided wallet
+func (c *client) Cash(deadline time.Time, data string, coins *models.Wallet) error {
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
 	res := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(res)
 
+	w := bodyWriter(req)
+	w.String(data)
+	if err := setBody(req, &w); err != nil {
+		return err
+	}
+
 	req.SetRequestURI(c.urls.cash)
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
-	req.SetBody(bts)
 
-	err = c.httpClient.DoDeadline(req, res, deadline)
+	err := c.httpClient.DoDeadline(req, res, deadline)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	switch res.StatusCode() {
 	case 200:
-		var wallet []uint32
-		if err := jsoniter.Unmarshal(res.Body(), &wallet); err != nil {
-			return nil, err
-		}
-		return wallet, nil
+		return coins.UnmarshalJSON(res.Body())
 	case 409:
-		return nil, TreasureIsNotDugErr{}
+		var response models.Error
+		if err := response.UnmarshalJSON(res.Body()); err != nil {
+			return err
+		}
+		return specError(res.StatusCode(), response.Code)
 	default:
-		return nil, NotStatedErr{}
+		return NotStatedErr{}
 	}
 }
 
@@ -185,23 +180,23 @@ func (c *client) Explore(deadline time.Time, data models.Area) (models.Report, e
 
 	var report models.Report
 
-	bts, err := jsoniter.Marshal(data)
-	if err != nil {
-		return report, err
-	}
-
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
 	res := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(res)
 
+	w := bodyWriter(req)
+	data.MarshalEasyJSON(&w)
+	if err := setBody(req, &w); err != nil {
+		return report, err
+	}
+
 	req.SetRequestURI(c.urls.explore)
 	req.Header.SetMethod("POST")
 
 	req.Header.SetContentType("application/json")
-	req.SetBody(bts)
-	err = c.httpClient.DoDeadline(req, res, deadline)
+	err := c.httpClient.DoDeadline(req, res, deadline)
 
 	if err != nil {
 		return models.Report{}, err
@@ -245,6 +240,22 @@ func (c *client) HealthCheck(deadline time.Time) error {
 	}
 }
 
+// bodyWriter returns a json writer that reuses the body buffer of the pooled request
+func bodyWriter(req *fasthttp.Request) jwriter.Writer {
+	return jwriter.Writer{
+		Buffer: buffer.Buffer{Buf: req.SwapBody(nil)[:0]},
+	}
+}
+
+func setBody(req *fasthttp.Request, w *jwriter.Writer) error {
+	body, err := w.BuildBytes()
+	if err != nil {
+		return err
+	}
+	req.SwapBody(body)
+	return nil
+}
+
 func (c *client) setupUrls(baseUrl string) {
 	c.urls.licenses = fmt.Sprintf("%s/licenses", baseUrl)
 	c.urls.dig = fmt.Sprintf("%s/dig", baseUrl)
@@ -0,0 +1,51 @@
+// Code generated by internal/http/gen from swagger.yaml. DO NOT EDIT.
+
+package api
+
+// Error codes documented by the server spec.
+const (
+	WrongCoordinatesCode            int32 = 1000
+	WrongDepthCode                  int32 = 1001
+	NoMoreActiveLicensesAllowedCode int32 = 1002
+	TreasureIsNotDiggedCode         int32 = 1003
+)
+
+type WrongCoordinatesErr struct{}
+
+func (WrongCoordinatesErr) Error() string {
+	return "wrong coordinates"
+}
+
+type WrongDepthErr struct{}
+
+func (WrongDepthErr) Error() string {
+	return "wrong depth"
+}
+
+type NoMoreActiveLicensesAllowedErr struct{}
+
+func (NoMoreActiveLicensesAllowedErr) Error() string {
+	return "no more active licenses allowed"
+}
+
+type TreasureIsNotDiggedErr struct{}
+
+func (TreasureIsNotDiggedErr) Error() string {
+	return "treasure is not digged"
+}
+
+// specError maps an error response to the typed error documented for it.
+func specError(status int, code int32) error {
+	switch {
+	case status == 422 && code == WrongCoordinatesCode:
+		return WrongCoordinatesErr{}
+	case status == 422 && code == WrongDepthCode:
+		return WrongDepthErr{}
+	case status == 409 && code == NoMoreActiveLicensesAllowedCode:
+		return NoMoreActiveLicensesAllowedErr{}
+	case status == 409 && code == TreasureIsNotDiggedCode:
+		return TreasureIsNotDiggedErr{}
+	default:
+		return NotStatedErr{}
+	}
+}
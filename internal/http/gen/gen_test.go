@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-openapi/loads"
+)
+
+func TestGeneratedCodeMatchesSpec(t *testing.T) {
+	doc, err := loads.Spec("../spec/swagger.yaml")
+	if err != nil {
+		t.Fatal("failed to load spec:", err)
+	}
+	g := &generator{doc: doc}
+	problems, err := g.check("../models", "../api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		t.Error(p)
+	}
+}
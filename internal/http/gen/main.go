@@ -0,0 +1,448 @@
+// Command gen generates models, easyjson marshalers and the error mapping
+// from the server's swagger spec. Run it with -check, or go test, to verify
+// that the committed output and the hand-tuned client still match the spec.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+)
+
+const header = "// Code generated by internal/http/gen from swagger.yaml. DO NOT EDIT.\n\n"
+
+var (
+	specPath  = flag.String("spec", "internal/http/spec/swagger.yaml", "Swagger spec")
+	modelsDir = flag.String("models", "internal/http/models", "Models package directory")
+	apiDir    = flag.String("api", "internal/http/api", "Api package directory")
+	check     = flag.Bool("check", false, "Only verify that generated code and the client match the spec")
+)
+
+// clientMethods maps spec operation ids to methods of the hand-tuned client.
+// Operations that are missing here are not implemented by the client.
+var clientMethods = map[string]string{
+	"healthCheck":  "HealthCheck",
+	"listLicenses": "ListLicenses",
+	"issueLicense": "IssueLicenses",
+	"exploreArea":  "Explore",
+	"dig":          "Dig",
+	"cash":         "Cash",
+}
+
+var errorLine = regexp.MustCompile(`(?m)^\s*-\s*(\d+)\.(\d+):\s*(.+?)\s*$`)
+
+type specError struct {
+	Status  int
+	Code    int
+	Message string
+}
+
+func (e specError) name() string {
+	return camel(strings.Fields(e.Message))
+}
+
+func (e specError) TypeName() string {
+	return e.name() + "Err"
+}
+
+func (e specError) ConstName() string {
+	return e.name() + "Code"
+}
+
+func camel(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		switch strings.ToLower(w) {
+		case "id":
+			b.WriteString("ID")
+		default:
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	return b.String()
+}
+
+func goName(jsonName string) string {
+	if strings.HasSuffix(jsonName, "ID") {
+		return camel([]string{strings.TrimSuffix(jsonName, "ID"), "id"})
+	}
+	if jsonName == "id" {
+		return "ID"
+	}
+	return camel([]string{jsonName})
+}
+
+func parseErrors(text string) []specError {
+	var errs []specError
+	for _, m := range errorLine.FindAllStringSubmatch(text, -1) {
+		status, _ := strconv.Atoi(m[1])
+		code, _ := strconv.Atoi(m[2])
+		errs = append(errs, specError{Status: status, Code: code, Message: m[3]})
+	}
+	return errs
+}
+
+type generator struct {
+	doc *loads.Document
+}
+
+func (g *generator) definition(ref spec.Ref) (string, spec.Schema) {
+	name := path.Base(ref.String())
+	return name, g.doc.Spec().Definitions[name]
+}
+
+func (g *generator) goType(s spec.Schema) string {
+	if s.Ref.String() != "" {
+		name, def := g.definition(s.Ref)
+		if def.Type.Contains("object") || def.Type.Contains("array") {
+			return goName(name)
+		}
+		return g.goType(def)
+	}
+	switch {
+	case s.Type.Contains("integer"):
+		switch s.Format {
+		case "int32", "uint32":
+			return s.Format
+		}
+		return "int64"
+	case s.Type.Contains("number"):
+		return "float64"
+	case s.Type.Contains("boolean"):
+		return "bool"
+	case s.Type.Contains("string"):
+		return "string"
+	case s.Type.Contains("array"):
+		return "[]" + g.goType(*s.Items.Schema)
+	}
+	return "interface{}"
+}
+
+func comment(buf *bytes.Buffer, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fmt.Fprintf(buf, "// %s\n", strings.TrimSpace(line))
+	}
+}
+
+func (g *generator) models() ([]byte, error) {
+	defs := g.doc.Spec().Definitions
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(header)
+	buf.WriteString("package models\n\n")
+
+	for _, name := range names {
+		def := defs[name]
+		switch {
+		case def.Type.Contains("object"):
+			comment(buf, def.Description)
+			fmt.Fprintf(buf, "type %s struct {\n", goName(name))
+			props := make([]string, 0, len(def.Properties))
+			for prop := range def.Properties {
+				props = append(props, prop)
+			}
+			sort.Strings(props)
+			for _, prop := range props {
+				tag := prop
+				if !contains(def.Required, prop) {
+					tag += ",omitempty"
+				}
+				fmt.Fprintf(buf, "\t%s %s `json:\"%s\"`\n", goName(prop), g.goType(def.Properties[prop]), tag)
+			}
+			buf.WriteString("}\n\n")
+		case def.Type.Contains("array"):
+			comment(buf, def.Description)
+			// easyjson -all skips everything but structs
+			buf.WriteString("//easyjson:json\n")
+			fmt.Fprintf(buf, "type %s []%s\n\n", goName(name), g.goType(*def.Items.Schema))
+		}
+	}
+	return format.Source(buf.Bytes())
+}
+
+func (g *generator) errors() []specError {
+	errs := parseErrors(g.doc.Spec().Info.Description)
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Code < errs[j].Code
+	})
+	return errs
+}
+
+func (g *generator) errorMapping() ([]byte, error) {
+	errs := g.errors()
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(header)
+	buf.WriteString("package api\n\n")
+
+	buf.WriteString("// Error codes documented by the server spec.\nconst (\n")
+	for _, e := range errs {
+		fmt.Fprintf(buf, "\t%s int32 = %d\n", e.ConstName(), e.Code)
+	}
+	buf.WriteString(")\n\n")
+
+	for _, e := range errs {
+		fmt.Fprintf(buf, "type %s struct{}\n\n", e.TypeName())
+		fmt.Fprintf(buf, "func (%s) Error() string {\n\treturn %q\n}\n\n", e.TypeName(), e.Message)
+	}
+
+	buf.WriteString("// specError maps an error response to the typed error documented for it.\n")
+	buf.WriteString("func specError(status int, code int32) error {\n\tswitch {\n")
+	for _, e := range errs {
+		fmt.Fprintf(buf, "\tcase status == %d && code == %s:\n\t\treturn %s{}\n", e.Status, e.ConstName(), e.TypeName())
+	}
+	buf.WriteString("\tdefault:\n\t\treturn NotStatedErr{}\n\t}\n}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// operations returns the documented errors of every operation, keyed by id.
+func (g *generator) operations() map[string][]specError {
+	ops := map[string][]specError{}
+	for _, item := range g.doc.Spec().Paths.Paths {
+		for _, op := range []*spec.Operation{item.Get, item.Post, item.Put, item.Delete} {
+			if op == nil {
+				continue
+			}
+			var errs []specError
+			if op.Responses != nil && op.Responses.Default != nil {
+				errs = parseErrors(op.Responses.Default.Description)
+			}
+			ops[op.ID] = errs
+		}
+	}
+	return ops
+}
+
+func contains(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
+
+func easyjson(file string) error {
+	cmd := exec.Command("go", "run", "github.com/mailru/easyjson/easyjson", "-all", filepath.Base(file))
+	cmd.Dir = filepath.Dir(file)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// clientCases collects, for every method of the client, the status codes of
+// its response switch and the identifiers used in each case.
+func clientCases(file string) (map[string]map[int][]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	methods := map[string]map[int][]string{}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || fn.Body == nil {
+			continue
+		}
+		cases := map[int][]string{}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			sw, ok := n.(*ast.SwitchStmt)
+			if !ok || sw.Tag == nil {
+				return true
+			}
+			call, ok := sw.Tag.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "StatusCode" {
+				return true
+			}
+			for _, stmt := range sw.Body.List {
+				clause := stmt.(*ast.CaseClause)
+				var idents []string
+				ast.Inspect(clause, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						idents = append(idents, id.Name)
+					}
+					return true
+				})
+				for _, expr := range clause.List {
+					if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.INT {
+						status, _ := strconv.Atoi(lit.Value)
+						cases[status] = idents
+					}
+				}
+			}
+			return false
+		})
+		methods[fn.Name.Name] = cases
+	}
+	return methods, nil
+}
+
+// checkClient reports every documented error the client does not map to its
+// typed error, either directly or through specError.
+func (g *generator) checkClient(file string) ([]string, error) {
+	methods, err := clientCases(file)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	for id, errs := range g.operations() {
+		name, ok := clientMethods[id]
+		if !ok {
+			continue
+		}
+		cases, ok := methods[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: client has no method %s", id, name))
+			continue
+		}
+		for _, e := range errs {
+			idents, ok := cases[e.Status]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: status %d is not handled (%s)", name, e.Status, e.Message))
+				continue
+			}
+			if !contains(idents, e.TypeName()) && !contains(idents, "specError") {
+				problems = append(problems, fmt.Sprintf("%s: %d.%d is not mapped to %s", name, e.Status, e.Code, e.TypeName()))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// checkEasyJSON reports every model without generated easyjson marshalers.
+func checkEasyJSON(models []byte, file string) ([]string, error) {
+	fset := token.NewFileSet()
+	src, err := parser.ParseFile(fset, "models.go", models, 0)
+	if err != nil {
+		return nil, err
+	}
+	gen, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	marshalers := map[string]bool{}
+	for _, decl := range gen.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && fn.Name.Name == "MarshalEasyJSON" {
+			if id, ok := fn.Recv.List[0].Type.(*ast.Ident); ok {
+				marshalers[id.Name] = true
+			}
+		}
+	}
+	var problems []string
+	for name := range src.Scope.Objects {
+		if !marshalers[name] {
+			problems = append(problems, fmt.Sprintf("%s: no easyjson marshaler in %s", name, filepath.Base(file)))
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+func checkFile(file string, want []byte) []string {
+	got, err := ioutil.ReadFile(file)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if !bytes.Equal(got, want) {
+		return []string{fmt.Sprintf("%s is out of date with the spec, run go generate", file)}
+	}
+	return nil
+}
+
+// check reports everything in the models and api directories that diverged
+// from the spec
+func (g *generator) check(modelsDir, apiDir string) ([]string, error) {
+	models, err := g.models()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate models: %v", err)
+	}
+	mapping, err := g.errorMapping()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate error mapping: %v", err)
+	}
+	problems := append(
+		checkFile(filepath.Join(modelsDir, "models.go"), models),
+		checkFile(filepath.Join(apiDir, "errors_gen.go"), mapping)...,
+	)
+
+	easyProblems, err := checkEasyJSON(models, filepath.Join(modelsDir, "models_easyjson.go"))
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, easyProblems...)
+
+	clientProblems, err := g.checkClient(filepath.Join(apiDir, "client.go"))
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, clientProblems...), nil
+}
+
+func main() {
+	flag.Parse()
+
+	doc, err := loads.Spec(*specPath)
+	if err != nil {
+		log.Fatalln("failed to load spec:", err)
+	}
+	g := &generator{doc: doc}
+
+	if *check {
+		problems, err := g.check(*modelsDir, *apiDir)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, p := range problems {
+			log.Println(p)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	models, err := g.models()
+	if err != nil {
+		log.Fatalln("failed to generate models:", err)
+	}
+	mapping, err := g.errorMapping()
+	if err != nil {
+		log.Fatalln("failed to generate error mapping:", err)
+	}
+	modelsFile := filepath.Join(*modelsDir, "models.go")
+	if err := ioutil.WriteFile(modelsFile, models, 0644); err != nil {
+		log.Fatalln(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(*apiDir, "errors_gen.go"), mapping, 0644); err != nil {
+		log.Fatalln(err)
+	}
+	if err := easyjson(modelsFile); err != nil {
+		log.Fatalln("easyjson failed:", err)
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import "testing"
+
+var (
+	benchCoins     = []byte(`[101,102,103,104,105,106,107,108,109,110]`)
+	benchTreasures = []byte(`["tr4s0re-1d-0","tr4s0re-1d-1","tr4s0re-1d-2"]`)
+	benchNothing   = []byte(`[]`)
+)
+
+// TestDecodeAllocs checks that pooled lists decode without allocating,
+// except for the ids of found treasures which outlive the response.
+func TestDecodeAllocs(t *testing.T) {
+	cases := []struct {
+		name   string
+		decode func()
+		allocs float64
+	}{
+		{
+			name: "wallet",
+			decode: func() {
+				w := AcquireWallet()
+				if err := w.UnmarshalJSON(benchCoins); err != nil || len(*w) != 10 {
+					t.Fatal("bad wallet", *w, err)
+				}
+				ReleaseWallet(w)
+			},
+		},
+		{
+			name: "no treasures",
+			decode: func() {
+				l := AcquireTreasureList()
+				if err := l.UnmarshalJSON(benchNothing); err != nil || len(*l) != 0 {
+					t.Fatal("bad treasure list", *l, err)
+				}
+				ReleaseTreasureList(l)
+			},
+		},
+		{
+			name: "treasures",
+			decode: func() {
+				l := AcquireTreasureList()
+				if err := l.UnmarshalJSON(benchTreasures); err != nil || len(*l) != 3 {
+					t.Fatal("bad treasure list", *l, err)
+				}
+				ReleaseTreasureList(l)
+			},
+			allocs: 3,
+		},
+	}
+	for _, c := range cases {
+		if got := testing.AllocsPerRun(100, c.decode); got != c.allocs {
+			t.Errorf("%s: %v allocs per decode, want %v", c.name, got, c.allocs)
+		}
+	}
+}
+
+func BenchmarkDecodeWallet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w := AcquireWallet()
+		if err := w.UnmarshalJSON(benchCoins); err != nil {
+			b.Fatal(err)
+		}
+		ReleaseWallet(w)
+	}
+}
+
+func BenchmarkDecodeTreasureList(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := AcquireTreasureList()
+		if err := l.UnmarshalJSON(benchTreasures); err != nil {
+			b.Fatal(err)
+		}
+		ReleaseTreasureList(l)
+	}
+}
@@ -1,31 +1,57 @@
+// Code generated by internal/http/gen from swagger.yaml. DO NOT EDIT.
+
 package models
 
+type Area struct {
+	PosX  int64 `json:"posX"`
+	PosY  int64 `json:"posY"`
+	SizeX int64 `json:"sizeX,omitempty"`
+	SizeY int64 `json:"sizeY,omitempty"`
+}
+
+// Current balance and wallet with up to 1000 coins.
+type Balance struct {
+	Balance uint32 `json:"balance"`
+	Wallet  Wallet `json:"wallet"`
+}
+
 type Dig struct {
-	Depth int64 `json:"depth"`
+	Depth     int64 `json:"depth"`
 	LicenseID int64 `json:"licenseID"`
-	PosX int64 `json:"posX"`
-	PosY int64 `json:"posY"`
+	PosX      int64 `json:"posX"`
+	PosY      int64 `json:"posY"`
 }
 
+// This model should match output of errors returned by go-swagger
+// (like failed validation), to ensure our handlers use same format.
+type Error struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// License for digging.
 type License struct {
 	DigAllowed int64 `json:"digAllowed"`
-	DigUsed int64 `json:"digUsed"`
-	ID int64 `json:"id"`
+	DigUsed    int64 `json:"digUsed"`
+	ID         int64 `json:"id"`
 }
 
-type Area struct {
-	PosX int64 `json:"posX"`
-	PosY int64 `json:"posY"`
-	SizeX int64 `json:"sizeX,omitempty"`
-	SizeY int64 `json:"sizeY,omitempty"`
-}
+// List of issued licenses.
+//
+//easyjson:json
+type LicenseList []License
 
 type Report struct {
 	Amount int64 `json:"amount"`
-	Area Area `json:"area"`
+	Area   Area  `json:"area"`
 }
 
-type Error struct {
-	Code int32 `json:"code"`
-	Message string `json:"message"`
-}
\ No newline at end of file
+// List of treasures.
+//
+//easyjson:json
+type TreasureList []string
+
+// Wallet with some coins.
+//
+//easyjson:json
+type Wallet []uint32
@@ -17,7 +17,139 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(in *jlexer.Lexer, out *Report) {
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(in *jlexer.Lexer, out *Wallet) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(Wallet, 0, 16)
+			} else {
+				*out = Wallet{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v1 uint32
+			v1 = uint32(in.Uint32())
+			*out = append(*out, v1)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(out *jwriter.Writer, in Wallet) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v2, v3 := range in {
+			if v2 > 0 {
+				out.RawByte(',')
+			}
+			out.Uint32(uint32(v3))
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Wallet) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Wallet) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Wallet) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Wallet) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(in *jlexer.Lexer, out *TreasureList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(TreasureList, 0, 4)
+			} else {
+				*out = TreasureList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v4 string
+			v4 = string(in.String())
+			*out = append(*out, v4)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(out *jwriter.Writer, in TreasureList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v5, v6 := range in {
+			if v5 > 0 {
+				out.RawByte(',')
+			}
+			out.String(string(v6))
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v TreasureList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v TreasureList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *TreasureList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *TreasureList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(in *jlexer.Lexer, out *Report) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -50,7 +182,7 @@ func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(out *jwriter.Writer, in Report) {
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(out *jwriter.Writer, in Report) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -70,27 +202,93 @@ func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 // MarshalJSON supports json.Marshaler interface
 func (v Report) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(&w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Report) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Report) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(&r, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Report) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels(l, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(l, v)
 }
-func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(in *jlexer.Lexer, out *License) {
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(in *jlexer.Lexer, out *LicenseList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(LicenseList, 0, 2)
+			} else {
+				*out = LicenseList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v7 License
+			(v7).UnmarshalEasyJSON(in)
+			*out = append(*out, v7)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(out *jwriter.Writer, in LicenseList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v8, v9 := range in {
+			if v8 > 0 {
+				out.RawByte(',')
+			}
+			(v9).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v LicenseList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v LicenseList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *LicenseList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *LicenseList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(in *jlexer.Lexer, out *License) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -125,7 +323,7 @@ func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(out *jwriter.Writer, in License) {
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(out *jwriter.Writer, in License) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -150,27 +348,27 @@ func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 // MarshalJSON supports json.Marshaler interface
 func (v License) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(&w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v License) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *License) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(&r, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *License) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels1(l, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(l, v)
 }
-func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(in *jlexer.Lexer, out *Error) {
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels5(in *jlexer.Lexer, out *Error) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -203,7 +401,7 @@ func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(out *jwriter.Writer, in Error) {
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels5(out *jwriter.Writer, in Error) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -223,27 +421,27 @@ func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 // MarshalJSON supports json.Marshaler interface
 func (v Error) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(&w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels5(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Error) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels5(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Error) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(&r, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels5(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Error) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels2(l, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels5(l, v)
 }
-func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(in *jlexer.Lexer, out *Dig) {
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels6(in *jlexer.Lexer, out *Dig) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -280,7 +478,7 @@ func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(out *jwriter.Writer, in Dig) {
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels6(out *jwriter.Writer, in Dig) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -310,27 +508,100 @@ func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 // MarshalJSON supports json.Marshaler interface
 func (v Dig) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(&w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels6(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Dig) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels6(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Dig) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(&r, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels6(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Dig) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels3(l, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels6(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels7(in *jlexer.Lexer, out *Balance) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "balance":
+			out.Balance = uint32(in.Uint32())
+		case "wallet":
+			(out.Wallet).UnmarshalEasyJSON(in)
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels7(out *jwriter.Writer, in Balance) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"balance\":"
+		out.RawString(prefix[1:])
+		out.Uint32(uint32(in.Balance))
+	}
+	{
+		const prefix string = ",\"wallet\":"
+		out.RawString(prefix)
+		(in.Wallet).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Balance) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels7(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Balance) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels7(w, v)
 }
-func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(in *jlexer.Lexer, out *Area) {
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Balance) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels7(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Balance) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels7(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels8(in *jlexer.Lexer, out *Area) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -367,7 +638,7 @@ func easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(out *jwriter.Writer, in Area) {
+func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels8(out *jwriter.Writer, in Area) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -397,23 +668,23 @@ func easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpMod
 // MarshalJSON supports json.Marshaler interface
 func (v Area) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(&w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels8(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Area) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(w, v)
+	easyjsonD2b7633eEncodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels8(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Area) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(&r, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels8(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Area) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels4(l, v)
+	easyjsonD2b7633eDecodeGithubComRomanIschenkoGoldenRushMailruInternalHttpModels8(l, v)
 }
@@ -0,0 +1,41 @@
+package models
+
+import "sync"
+
+var treasureListPool = sync.Pool{
+	New: func() interface{} {
+		return &TreasureList{}
+	},
+}
+
+var walletPool = sync.Pool{
+	New: func() interface{} {
+		return &Wallet{}
+	},
+}
+
+// AcquireTreasureList returns an empty treasure list, which keeps its capacity
+// between uses. Release it with ReleaseTreasureList when it is no longer needed.
+func AcquireTreasureList() *TreasureList {
+	return treasureListPool.Get().(*TreasureList)
+}
+
+func ReleaseTreasureList(l *TreasureList) {
+	// drop the references so that the pool doesn't keep treasures alive
+	for i := range *l {
+		(*l)[i] = ""
+	}
+	*l = (*l)[:0]
+	treasureListPool.Put(l)
+}
+
+// AcquireWallet returns an empty wallet, which keeps its capacity between uses.
+// Release it with ReleaseWallet when it is no longer needed.
+func AcquireWallet() *Wallet {
+	return walletPool.Get().(*Wallet)
+}
+
+func ReleaseWallet(w *Wallet) {
+	*w = (*w)[:0]
+	walletPool.Put(w)
+}
@@ -3,6 +3,7 @@ package poller
 import (
 	"errors"
 	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
 	"time"
 )
 
@@ -12,12 +13,21 @@ type Poller struct {
 	timeout time.Duration
 	interval time.Duration
 	maxIterations int
+	log *logger.Logger
+}
+
+// retry logs an attempt that is tried again
+func (p *Poller) retry(attempt int, err error) {
+	if p.log.Enabled(logger.Debug) {
+		p.log.Debug("retrying request", logger.F("attempt", attempt), logger.F("error", err))
+	}
 }
 
 func (p *Poller) Do(f func(deadline time.Time) (interface{}, error, bool)) (interface{}, error) {
 	i := 0
 	for {
 		if i >= p.maxIterations && p.maxIterations > 0 {
+			p.log.Warn("giving up request", logger.F("attempts", i))
 			return nil, MaxIterationsReachedErr
 		}
 		i++
@@ -27,6 +37,7 @@ func (p *Poller) Do(f func(deadline time.Time) (interface{}, error, bool)) (inte
 		if ok {
 			return data, err
 		}
+		p.retry(i, err)
 
 		if p.interval > 0 {
 			time.Sleep(p.interval)
@@ -41,6 +52,7 @@ func (p *Poller) DoDeadline(deadline time.Time, f func(deadline time.Time) (inte
 			return nil, DeadlineReachedErr
 		}
 		if i >= p.maxIterations && p.maxIterations > 0 {
+			p.log.Warn("giving up request", logger.F("attempts", i))
 			return nil, MaxIterationsReachedErr
 		}
 		i++
@@ -48,17 +60,19 @@ func (p *Poller) DoDeadline(deadline time.Time, f func(deadline time.Time) (inte
 		if ok {
 			return data, err
 		}
+		p.retry(i, err)
 		if p.interval > 0 {
 			time.Sleep(p.interval)
 		}
 	}
 }
 
-func FromConfig(cfg config.PollerConfig) *Poller {
+func FromConfig(cfg config.PollerConfig, log *logger.Logger) *Poller {
 	return &Poller{
 		timeout:       cfg.TimeOut.Parse(),
 		interval:      cfg.Interval.Parse(),
 		maxIterations: cfg.MaxIters,
+		log:           log,
 	}
 }
 
@@ -67,5 +81,6 @@ func NewPoller(timeout time.Duration, maxIterations int, interval time.Duration)
 		timeout:       timeout,
 		maxIterations: maxIterations,
 		interval: interval,
+		log: logger.Nop(),
 	}
 }
\ No newline at end of file
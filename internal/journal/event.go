@@ -0,0 +1,73 @@
+package journal
+
+import (
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/http/models"
+)
+
+type EventType string
+
+const (
+	ExploreEvent EventType = "explore"
+	DigEvent     EventType = "dig"
+	CashEvent    EventType = "cash"
+	LicenseEvent EventType = "license"
+	PriceEvent   EventType = "price"
+)
+
+// Event is a single line of the journal, only the payload matching Type is set
+type Event struct {
+	Time    time.Time    `json:"time"`
+	Type    EventType    `json:"type"`
+	Explore *Explore     `json:"explore,omitempty"`
+	Dig     *Dig         `json:"dig,omitempty"`
+	Cash    *Cash        `json:"cash,omitempty"`
+	License *License     `json:"license,omitempty"`
+	Price   *PriceChange `json:"price,omitempty"`
+}
+
+type Explore struct {
+	Report  models.Report `json:"report"`
+	Latency time.Duration `json:"latency"`
+	// pre-exploration runs before digging starts
+	Pre   bool   `json:"pre,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type Dig struct {
+	X         int64         `json:"x"`
+	Y         int64         `json:"y"`
+	Depth     int64         `json:"depth"`
+	LicenseID int64         `json:"license_id"`
+	Latency   time.Duration `json:"latency"`
+	Treasures int           `json:"treasures"`
+	Error     string        `json:"error,omitempty"`
+}
+
+type Cash struct {
+	Treasure string        `json:"treasure"`
+	Coins    int           `json:"coins"`
+	Latency  time.Duration `json:"latency"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type License struct {
+	ID           int64  `json:"id"`
+	Price        int64  `json:"price"`
+	Digs         int64  `json:"digs"`
+	Experimental bool   `json:"experimental,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type PriceChange struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
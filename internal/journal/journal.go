@@ -0,0 +1,216 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/http/models"
+)
+
+const filePattern = "journal-*.jsonl"
+
+// Journal is an append-only JSONL log of game actions. Events are queued and
+// written by a background goroutine, so emitting never blocks: when the queue
+// is full the event is dropped and counted. A nil journal discards everything.
+type Journal struct {
+	dir           string
+	maxFileSize   int64
+	flushInterval time.Duration
+
+	events  chan Event
+	dropped int64
+	done    chan struct{}
+	once    sync.Once
+	// guards events against sends after Close, workers may still emit
+	// while the app shuts down
+	mu     sync.RWMutex
+	closed bool
+
+	file    *os.File
+	writer  *bufio.Writer
+	written int64
+}
+
+func (j *Journal) emit(e Event) {
+	if j == nil {
+		return
+	}
+	e.Time = time.Now()
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.closed {
+		atomic.AddInt64(&j.dropped, 1)
+		return
+	}
+	select {
+	case j.events <- e:
+	default:
+		atomic.AddInt64(&j.dropped, 1)
+	}
+}
+
+func (j *Journal) Explored(report models.Report, latency time.Duration, pre bool, err error) {
+	j.emit(Event{Type: ExploreEvent, Explore: &Explore{
+		Report:  report,
+		Latency: latency,
+		Pre:     pre,
+		Error:   errorString(err),
+	}})
+}
+
+func (j *Journal) Dug(data models.Dig, latency time.Duration, treasures int, err error) {
+	j.emit(Event{Type: DigEvent, Dig: &Dig{
+		X:         data.PosX,
+		Y:         data.PosY,
+		Depth:     data.Depth,
+		LicenseID: data.LicenseID,
+		Latency:   latency,
+		Treasures: treasures,
+		Error:     errorString(err),
+	}})
+}
+
+func (j *Journal) Cashed(treasure string, coins int, latency time.Duration, err error) {
+	j.emit(Event{Type: CashEvent, Cash: &Cash{
+		Treasure: treasure,
+		Coins:    coins,
+		Latency:  latency,
+		Error:    errorString(err),
+	}})
+}
+
+func (j *Journal) LicenseIssued(id, price, digs int64, experimental bool, err error) {
+	j.emit(Event{Type: LicenseEvent, License: &License{
+		ID:           id,
+		Price:        price,
+		Digs:         digs,
+		Experimental: experimental,
+		Error:        errorString(err),
+	}})
+}
+
+func (j *Journal) PriceChanged(from, to int64) {
+	j.emit(Event{Type: PriceEvent, Price: &PriceChange{
+		From: from,
+		To:   to,
+	}})
+}
+
+// Dropped returns the number of events lost because the queue was full
+func (j *Journal) Dropped() int64 {
+	if j == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&j.dropped)
+}
+
+func (j *Journal) rotate() error {
+	if j.file != nil {
+		if err := j.writer.Flush(); err != nil {
+			return err
+		}
+		if err := j.file.Close(); err != nil {
+			return err
+		}
+	}
+	name := fmt.Sprintf("journal-%s.jsonl", time.Now().UTC().Format("20060102T150405.000000000"))
+	f, err := os.OpenFile(filepath.Join(j.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	j.file = f
+	j.writer = bufio.NewWriterSize(f, 64*1024)
+	j.written = 0
+	return nil
+}
+
+func (j *Journal) write(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if j.maxFileSize > 0 && j.written+int64(len(data))+1 > j.maxFileSize && j.written > 0 {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.writer.Write(append(data, '\n'))
+	j.written += int64(n)
+	return err
+}
+
+func (j *Journal) run(errs func(error)) {
+	defer close(j.done)
+	ticker := time.NewTicker(j.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-j.events:
+			if !ok {
+				if err := j.writer.Flush(); err != nil {
+					errs(err)
+				}
+				if err := j.file.Close(); err != nil {
+					errs(err)
+				}
+				return
+			}
+			if err := j.write(e); err != nil {
+				errs(err)
+			}
+		case <-ticker.C:
+			if err := j.writer.Flush(); err != nil {
+				errs(err)
+			}
+		}
+	}
+}
+
+// Close writes the queued events and closes the current file, events
+// emitted after Close are dropped.
+func (j *Journal) Close() {
+	if j == nil {
+		return
+	}
+	j.once.Do(func() {
+		j.mu.Lock()
+		j.closed = true
+		close(j.events)
+		j.mu.Unlock()
+		<-j.done
+	})
+}
+
+// New creates the journal directory and starts writing to it, write errors
+// are passed to errs. It returns nil if the journal is disabled.
+func New(cfg config.JournalConfig, errs func(error)) (*Journal, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+	flushInterval := time.Second
+	if cfg.FlushInterval != "" {
+		flushInterval = cfg.FlushInterval.Parse()
+	}
+	j := &Journal{
+		dir:           cfg.Dir,
+		maxFileSize:   cfg.MaxFileSize,
+		flushInterval: flushInterval,
+		events:        make(chan Event, cfg.BufferSize),
+		done:          make(chan struct{}),
+	}
+	if err := j.rotate(); err != nil {
+		return nil, err
+	}
+	go j.run(errs)
+	return j, nil
+}
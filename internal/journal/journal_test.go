@@ -0,0 +1,91 @@
+package journal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func newJournal(t *testing.T, cfg config.JournalConfig) *Journal {
+	t.Helper()
+	cfg.Enabled = true
+	cfg.Dir = t.TempDir()
+	j, err := New(cfg, func(err error) { t.Error(err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func TestJournalWritesEvents(t *testing.T) {
+	j := newJournal(t, config.JournalConfig{BufferSize: 16})
+	j.PriceChanged(1, 2)
+	j.Cashed("t", 3, time.Millisecond, nil)
+	j.Close()
+
+	var events []Event
+	if err := ReadDir(j.dir, func(e Event) error {
+		events = append(events, e)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != PriceEvent || events[1].Cash.Coins != 3 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func TestJournalRotates(t *testing.T) {
+	j := newJournal(t, config.JournalConfig{BufferSize: 16, MaxFileSize: 100})
+	for i := 0; i < 5; i++ {
+		j.PriceChanged(int64(i), int64(i+1))
+	}
+	j.Close()
+	files, err := Files(j.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("expected rotated files, got %v", files)
+	}
+}
+
+func TestJournalEmitAfterClose(t *testing.T) {
+	j := newJournal(t, config.JournalConfig{BufferSize: 1})
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					j.PriceChanged(1, 2)
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	j.Close()
+	// workers still emitting after Close must not panic
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	if j.Dropped() == 0 {
+		t.Fatal("expected events emitted after Close to be dropped")
+	}
+}
+
+func TestNilJournal(t *testing.T) {
+	var j *Journal
+	j.PriceChanged(1, 2)
+	j.Close()
+	if j.Dropped() != 0 {
+		t.Fatal("nil journal counted drops")
+	}
+}
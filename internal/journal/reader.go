@@ -0,0 +1,68 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Read calls f for every event of a JSONL stream
+func Read(r io.Reader, f func(Event) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Files returns the journal files of a directory in the order they were written
+func Files(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, filePattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadDir calls f for every event of every journal file in the directory
+func ReadDir(dir string, f func(Event) error) error {
+	files, err := Files(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := ReadFile(name, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ReadFile(name string, f func(Event) error) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := Read(file, f); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package optimizers
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/stats"
+)
+
+type depthModel struct {
+	digs int64
+	// treasures the cells still had when dug at this depth and the ones found
+	remaining, found int64
+	latency          time.Duration
+	cashed, coins    int64
+}
+
+// DigModel decides per cell how deep digging pays off. At every depth it
+// keeps a beta posterior of the share of a cell's remaining treasures that
+// are found there, with a prior that spreads them evenly over the depths
+// left, and the coins per treasure. Since it conditions on the treasures
+// left, cells that stop early don't bias the deeper depths.
+//
+// A dig costs the coins paid per permit and the coins the digger would
+// have earned at the average rate in the time the dig takes. A cell is dug
+// on while some deeper stop has a positive expected value, with shares
+// drawn from their posteriors so depths that look bad are still tried now
+// and then.
+type DigModel struct {
+	mu     sync.Mutex
+	config config.DigModelConfig
+	// samplers of the diggers sampling outside mu
+	samplers sync.Pool
+	depths   []depthModel
+	// permits bought and the coins paid for them
+	permits, permitCoins int64
+}
+
+// posterior is what the estimates are computed from, copied under mu so
+// diggers sample from it without holding the lock
+type posterior struct {
+	depths               []depthModel
+	permits, permitCoins int64
+}
+
+// sampler is the random source and the posterior copy of one digger
+type sampler struct {
+	rand      *rand.Rand
+	posterior posterior
+}
+
+var seed = time.Now().UnixNano()
+
+// NewDigModel returns nil if the model is disabled, a nil model never stops
+// digging.
+func NewDigModel(cfg config.DigModelConfig, depth int) *DigModel {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &DigModel{
+		config: cfg,
+		samplers: sync.Pool{New: func() interface{} {
+			return &sampler{rand: rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))}
+		}},
+		depths: make([]depthModel, depth),
+	}
+}
+
+// copyPosterior copies the posterior into p reusing its depths
+func (m *DigModel) copyPosterior(p *posterior) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p.depths = append(p.depths[:0], m.depths...)
+	p.permits, p.permitCoins = m.permits, m.permitCoins
+}
+
+func (m *DigModel) at(depth int64) *depthModel {
+	if depth < 1 || depth > int64(len(m.depths)) {
+		return nil
+	}
+	return &m.depths[depth-1]
+}
+
+// Dug records a dig at the depth of a cell that had remaining treasures left
+func (m *DigModel) Dug(depth, remaining int64, found int, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.at(depth)
+	if d == nil {
+		return
+	}
+	d.digs++
+	d.remaining += remaining
+	d.found += int64(found)
+	d.latency += latency
+}
+
+// Cashed records the coins of a treasure dug at the depth
+func (m *DigModel) Cashed(depth int64, coins int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d := m.at(depth); d != nil {
+		d.cashed++
+		d.coins += int64(coins)
+	}
+}
+
+// LicenseIssued records the coins paid for the digs of a license
+func (m *DigModel) LicenseIssued(price, digs int64) {
+	if m == nil || digs <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.permits += digs
+	m.permitCoins += price
+}
+
+// DepthEstimate is what the model believes about one depth
+type DepthEstimate struct {
+	Depth int64 `json:"depth"`
+	Digs  int64 `json:"digs"`
+	// share of the treasures left in a cell that are found at this depth
+	Share            float64 `json:"share"`
+	CoinsPerTreasure float64 `json:"coins_per_treasure"`
+	Latency          float64 `json:"latency_ms"`
+	// coins a dig at this depth costs
+	Cost float64 `json:"cost"`
+}
+
+// estimates returns the posterior means of every depth, or the shares drawn
+// from their posteriors with r if it isn't nil. ok is false while there are
+// fewer digs than MinSamples.
+func (m *DigModel) estimates(p posterior, r *rand.Rand) (e []DepthEstimate, ok bool) {
+	var digs, cashed, coins, found int64
+	var latency time.Duration
+	for _, d := range p.depths {
+		digs += d.digs
+		cashed += d.cashed
+		coins += d.coins
+		found += d.found
+		latency += d.latency
+	}
+	// the average coins per treasure and dig latency are the priors of
+	// the depths that have few samples
+	coinsPerTreasure := 0.
+	if cashed > 0 {
+		coinsPerTreasure = float64(coins) / float64(cashed)
+	}
+	meanLatency := 0.
+	if digs > 0 {
+		meanLatency = latency.Seconds() / float64(digs)
+	}
+	permitCost := 0.
+	if p.permits > 0 {
+		permitCost = float64(p.permitCoins) / float64(p.permits)
+	}
+	// net coins per second of digging, what the time of a dig is worth
+	rate := 0.
+	if latency > 0 {
+		rate = (float64(found)*coinsPerTreasure - float64(digs)*permitCost) / latency.Seconds()
+		if rate < 0 {
+			rate = 0
+		}
+	}
+
+	n := len(p.depths)
+	e = make([]DepthEstimate, n)
+	for i, d := range p.depths {
+		left := float64(n - i)
+		a := m.config.Prior / left
+		b := m.config.Prior - a
+		share := 1 / left
+		alpha, beta := float64(d.found)+a, float64(d.remaining-d.found)+b
+		if r != nil && alpha > 0 && beta > 0 {
+			x := stats.Gamma(r, alpha, 1)
+			share = x / (x + stats.Gamma(r, beta, 1))
+		} else if alpha+beta > 0 {
+			share = alpha / (alpha + beta)
+		}
+		if i == n-1 || share > 1 {
+			// whatever is left is at the last depth
+			share = 1
+		}
+		w := m.config.CoinsPrior
+		ct := coinsPerTreasure
+		if float64(d.cashed)+w > 0 {
+			ct = (float64(d.coins) + w*coinsPerTreasure) / (float64(d.cashed) + w)
+		}
+		lat := meanLatency
+		if d.digs > 0 {
+			lat = d.latency.Seconds() / float64(d.digs)
+		}
+		e[i] = DepthEstimate{
+			Depth:            int64(i + 1),
+			Digs:             d.digs,
+			Share:            share,
+			CoinsPerTreasure: ct,
+			Latency:          lat * 1000,
+			Cost:             permitCost + m.config.TimeWeight*rate*lat,
+		}
+	}
+	return e, digs >= int64(m.config.MinSamples) && cashed > 0
+}
+
+// Plan returns the depth a cell with remaining treasures left at the depth
+// is best dug to, it is less than the depth if the next dig doesn't pay off.
+// Before MinSamples digs it is the world depth.
+func (m *DigModel) Plan(depth, remaining int64) int64 {
+	if m == nil {
+		return math.MaxInt64
+	}
+	s := m.samplers.Get().(*sampler)
+	m.copyPosterior(&s.posterior)
+	e, ok := m.estimates(s.posterior, s.rand)
+	m.samplers.Put(s)
+	if !ok {
+		return int64(len(m.depths))
+	}
+	return plan(e, depth, remaining, m.config.Margin)
+}
+
+// plan picks the stop depth with the best expected value. The treasures
+// expected at each depth are the ones left times the share found there,
+// what isn't found carries on to the next depth.
+func plan(e []DepthEstimate, depth, remaining int64, margin float64) int64 {
+	best, bestValue := depth-1, 0.
+	value, left := 0., float64(remaining)
+	for d := depth; d <= int64(len(e)); d++ {
+		est := e[d-1]
+		found := left * est.Share
+		left -= found
+		value += found*est.CoinsPerTreasure - margin*est.Cost
+		if value > bestValue {
+			best, bestValue = d, value
+		}
+	}
+	return best
+}
+
+// Report returns the current estimates of every depth
+func (m *DigModel) Report() []DepthEstimate {
+	if m == nil {
+		return nil
+	}
+	var p posterior
+	m.copyPosterior(&p)
+	e, _ := m.estimates(p, nil)
+	return e
+}
@@ -0,0 +1,123 @@
+package optimizers
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+func TestPlan(t *testing.T) {
+	e := []DepthEstimate{
+		{Depth: 1, Share: 0.5, CoinsPerTreasure: 2, Cost: 1},
+		{Depth: 2, Share: 0.5, CoinsPerTreasure: 2, Cost: 1},
+		{Depth: 3, Share: 1, CoinsPerTreasure: 2, Cost: 10},
+	}
+	for _, c := range []struct {
+		depth, remaining int64
+		margin           float64
+		want             int64
+	}{
+		// the expensive last dig doesn't pay off
+		{1, 4, 1, 2},
+		{3, 1, 1, 2},
+		// without a margin every dig that finds something does
+		{1, 4, 0, 3},
+		// nothing left to find
+		{1, 0, 1, 0},
+	} {
+		if got := plan(e, c.depth, c.remaining, c.margin); got != c.want {
+			t.Errorf("plan from %d with %d left and margin %v = %d, want %d", c.depth, c.remaining, c.margin, got, c.want)
+		}
+	}
+
+	// a depth that finds nothing is dug through to the one that does
+	e = []DepthEstimate{
+		{Depth: 1, Share: 0, CoinsPerTreasure: 2, Cost: 1},
+		{Depth: 2, Share: 1, CoinsPerTreasure: 2, Cost: 1},
+	}
+	if got := plan(e, 1, 3, 1); got != 2 {
+		t.Fatalf("plan past an empty depth = %d, want 2", got)
+	}
+}
+
+func newDigModel(minSamples int) *DigModel {
+	return newDeepDigModel(minSamples, 3)
+}
+
+func newDeepDigModel(minSamples, depth int) *DigModel {
+	return NewDigModel(config.DigModelConfig{
+		Enabled:    true,
+		MinSamples: minSamples,
+		Prior:      1,
+		CoinsPrior: 1,
+		Margin:     1,
+	}, depth)
+}
+
+func TestDigModelLearnsShares(t *testing.T) {
+	m := newDigModel(10)
+	if got := m.Plan(1, 5); got != 3 {
+		t.Fatalf("plan before any digs = %d, want the world depth", got)
+	}
+	for i := 0; i < 1000; i++ {
+		// a quarter of the treasures left is found at the first depth
+		m.Dug(1, 4, 1, time.Millisecond)
+		m.Dug(2, 3, 3, time.Millisecond)
+		m.Cashed(1, 5)
+	}
+	e := m.Report()
+	if len(e) != 3 || math.Abs(e[0].Share-0.25) > 0.01 || e[1].Share < 0.99 {
+		t.Fatalf("estimates = %+v", e)
+	}
+	if e[0].CoinsPerTreasure != 5 || e[1].CoinsPerTreasure != 5 {
+		t.Fatalf("coins per treasure = %v, %v, want 5", e[0].CoinsPerTreasure, e[1].CoinsPerTreasure)
+	}
+	// a coin per dig, the third depth has nothing left to pay for it
+	m.LicenseIssued(1, 1)
+	if got := m.Plan(1, 4); got != 2 {
+		t.Fatalf("plan = %d, want 2", got)
+	}
+
+	// permits that cost more than any treasure brings stop every cell
+	m.LicenseIssued(1000, 1)
+	if got := m.Plan(1, 4); got != 0 {
+		t.Fatalf("plan with expensive permits = %d, want 0", got)
+	}
+}
+
+func TestNilDigModel(t *testing.T) {
+	m := NewDigModel(config.DigModelConfig{}, 3)
+	if m != nil {
+		t.Fatal("a disabled model was built")
+	}
+	m.Dug(1, 1, 1, time.Millisecond)
+	m.Cashed(1, 1)
+	m.LicenseIssued(1, 1)
+	if got := m.Plan(1, 1); got != math.MaxInt64 {
+		t.Fatalf("nil model plan = %d, want no limit", got)
+	}
+	if m.Report() != nil {
+		t.Fatal("nil model has a report")
+	}
+}
+
+// BenchmarkDigModelPlan plans and records digs from parallel diggers, to
+// measure how long they wait for each other on the hot path
+func BenchmarkDigModelPlan(b *testing.B) {
+	// the depth of the configured world
+	m := newDeepDigModel(1, 10)
+	m.LicenseIssued(10, 10)
+	for i := 0; i < 100; i++ {
+		m.Dug(1, 4, 1, time.Millisecond)
+		m.Cashed(1, 5)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Plan(1, 4)
+			m.Dug(1, 4, 1, time.Millisecond)
+		}
+	})
+}
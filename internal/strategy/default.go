@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/area"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+)
+
+// defaultStrategy explores everything, digs blocks with enough treasures,
+// takes the depth from the dig model or the depth optimizer and the license
+// price from the price controller, the wallet and the price list.
+type defaultStrategy struct {
+	deps         Deps
+	minTreasures int64
+}
+
+func NewDefault(deps Deps) Strategy {
+	return &defaultStrategy{
+		deps:         deps,
+		minTreasures: int64(deps.Config.App.MinTreasuresPerBlock),
+	}
+}
+
+func (s *defaultStrategy) ExploreArea(a area.Area) bool {
+	return true
+}
+
+func (s *defaultStrategy) KeepArea(a area.Area) bool {
+	return a.Treasures >= s.minTreasures
+}
+
+// MaxDepth is the world depth with the dig model, which stops cells on its
+// own, and the depth optimizer's threshold without it
+func (s *defaultStrategy) MaxDepth(x, y int64) int64 {
+	if s.deps.DigModel != nil {
+		return int64(s.deps.Config.App.World.Depth)
+	}
+	return s.deps.DepthOptimizer.Next()
+}
+
+func (s *defaultStrategy) PlanDepth(depth, remaining int64) int64 {
+	return s.deps.DigModel.Plan(depth, remaining)
+}
+
+func (s *defaultStrategy) Dug(depth int64, treasures int, latency time.Duration) {}
+
+// LicensePrice never asks for more coins than the wallet has, with an empty
+// wallet the license is free.
+func (s *defaultStrategy) LicensePrice() license.Price {
+	max := s.deps.PriceController.GetPrice()
+	if balance := s.deps.Wallet.Amount(); balance < max {
+		max = balance
+	}
+	return s.deps.PriceList.Next(max)
+}
+
+func (s *defaultStrategy) LicenseIssued(price license.Price) {
+	s.deps.PriceList.Commit(price)
+}
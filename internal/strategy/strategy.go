@@ -0,0 +1,96 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/area"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/coin"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/license"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/price_controller"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/optimizers"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/util/logger"
+)
+
+// Strategy makes the decisions of the game loop, the app calls it
+// concurrently from all workers.
+type Strategy interface {
+	// ExploreArea reports whether an unexplored block should be explored,
+	// skipped blocks are dropped.
+	ExploreArea(a area.Area) bool
+	// KeepArea reports whether an explored block is worth digging
+	KeepArea(a area.Area) bool
+	// MaxDepth returns the depth to stop digging the cell at
+	MaxDepth(x, y int64) int64
+	// PlanDepth returns the depth a cell with remaining treasures left is
+	// expected to be dug to, digging stops once it is less than the depth
+	PlanDepth(depth, remaining int64) int64
+	// Dug reports the result of a successful or empty dig
+	Dug(depth int64, treasures int, latency time.Duration)
+	// LicensePrice returns what to pay for the next license
+	LicensePrice() license.Price
+	// LicenseIssued reports the outcome of buying a license at the price
+	LicenseIssued(price license.Price)
+}
+
+// Deps are the shared components a strategy can build on
+type Deps struct {
+	Config          config.Config
+	Log             *logger.Logger
+	Wallet          *coin.Manager
+	PriceList       license.Pricer
+	PriceController price_controller.Controller
+	DepthOptimizer  *optimizers.DepthOptimizer
+	// nil if disabled
+	DigModel *optimizers.DigModel
+}
+
+type Factory func(deps Deps) Strategy
+
+const DefaultName = "default"
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Factory{
+		DefaultName: NewDefault,
+	}
+)
+
+// Register makes a strategy selectable by name in the config
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("strategy already registered: " + name)
+	}
+	registry[name] = f
+}
+
+// Names returns the registered strategies
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the strategy registered under name, the default one if name is empty
+func New(name string, deps Deps) (Strategy, error) {
+	if name == "" {
+		name = DefaultName
+	}
+	mu.RLock()
+	f, ok := registry[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, known: %v", name, Names())
+	}
+	return f(deps), nil
+}
@@ -0,0 +1,49 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+	"github.com/RomanIschenko/golden-rush-mailru/internal/entities/area"
+)
+
+type keepAll struct {
+	Strategy
+}
+
+func (keepAll) KeepArea(a area.Area) bool {
+	return true
+}
+
+func TestRegistry(t *testing.T) {
+	var cfg config.Config
+	cfg.App.MinTreasuresPerBlock = 2
+	deps := Deps{Config: cfg}
+
+	s, err := New("", deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.KeepArea(area.Area{Treasures: 1}) || !s.KeepArea(area.Area{Treasures: 2}) {
+		t.Fatal("the default strategy ignores min_treasures_per_block")
+	}
+
+	Register("test_keep_all", func(deps Deps) Strategy { return keepAll{NewDefault(deps)} })
+	if s, err = New("test_keep_all", deps); err != nil {
+		t.Fatal(err)
+	}
+	if !s.KeepArea(area.Area{}) {
+		t.Fatal("the registered strategy wasn't built")
+	}
+
+	if _, err := New("unknown", deps); err == nil {
+		t.Fatal("an unknown strategy was built")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a name twice didn't panic")
+		}
+	}()
+	Register(DefaultName, NewDefault)
+}
@@ -60,6 +60,6 @@ func main() {
 
 	w.Add([]uint32{1,2,3,4,5,6,6,7}...)
 
-	fmt.Println(w.PopNOrAll(3), w.PopNOrAll(3), w.PopNOrAll(7))
+	fmt.Println(w.Pop(3), w.Pop(3), w.Pop(7))
 	time.Sleep(time.Second * 20)
 }
\ No newline at end of file
@@ -0,0 +1,35 @@
+package condwait
+
+import (
+	"context"
+	"sync"
+)
+
+// Wait waits on c until ready or ctx is done, c.L must be held. A waiter
+// that gives up passes a wakeup it may have consumed on to the next.
+func Wait(ctx context.Context, c *sync.Cond, ready func() bool) error {
+	if ready() {
+		return nil
+	}
+	if done := ctx.Done(); done != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-done:
+				c.L.Lock()
+				c.Broadcast()
+				c.L.Unlock()
+			case <-stop:
+			}
+		}()
+	}
+	for !ready() {
+		if err := ctx.Err(); err != nil {
+			c.Signal()
+			return err
+		}
+		c.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package condwait
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitReady(t *testing.T) {
+	var mu sync.Mutex
+	c := sync.NewCond(&mu)
+	ready := false
+	done := make(chan error)
+	go func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done <- Wait(context.Background(), c, func() bool { return ready })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	ready = true
+	c.Signal()
+	mu.Unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	var mu sync.Mutex
+	c := sync.NewCond(&mu)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done <- Wait(ctx, c, func() bool { return false })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a cancelled waiter is still waiting")
+	}
+}
+
+// a signal reaches a waiter that takes the item even if another waiter
+// is cancelled at the same time
+func TestWaitCancelledWaiterKeepsSignal(t *testing.T) {
+	var mu sync.Mutex
+	c := sync.NewCond(&mu)
+	items := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	other, stop := context.WithCancel(context.Background())
+	defer stop()
+	took := make(chan struct{}, 2)
+	wait := func(ctx context.Context) {
+		mu.Lock()
+		defer mu.Unlock()
+		if Wait(ctx, c, func() bool { return items > 0 }) == nil {
+			items--
+			took <- struct{}{}
+		}
+	}
+	go wait(ctx)
+	go wait(other)
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	cancel()
+	items++
+	c.Signal()
+	mu.Unlock()
+
+	select {
+	case <-took:
+	case <-time.After(time.Second):
+		t.Fatal("the signal was lost")
+	}
+}
+
+func TestWaitWithoutDoneChannel(t *testing.T) {
+	var mu sync.Mutex
+	c := sync.NewCond(&mu)
+	mu.Lock()
+	defer mu.Unlock()
+	if err := Wait(context.Background(), c, func() bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,214 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RomanIschenko/golden-rush-mailru/internal/config"
+)
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Warn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return Debug, nil
+	case "", "info":
+		return Info, nil
+	case "warn":
+		return Warn, nil
+	case "error":
+		return Error, nil
+	}
+	return Info, fmt.Errorf("unknown log level: %s", s)
+}
+
+type Format int
+
+const (
+	Console Format = iota
+	JSON
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "console":
+		return Console, nil
+	case "json":
+		return JSON, nil
+	}
+	return Console, fmt.Errorf("unknown log format: %s", s)
+}
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+type output struct {
+	w      io.Writer
+	level  Level
+	format Format
+	mu     sync.Mutex
+}
+
+// Logger writes levelled entries with the fields attached by With
+// followed by the fields of the entry itself.
+type Logger struct {
+	out    *output
+	fields []Field
+}
+
+// With returns a logger that adds the fields to every entry
+func (l *Logger) With(fields ...Field) *Logger {
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+	return &Logger{
+		out:    l.out,
+		fields: all,
+	}
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.out.level
+}
+
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.log(Debug, msg, fields)
+}
+
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.log(Info, msg, fields)
+}
+
+func (l *Logger) Warn(msg string, fields ...Field) {
+	l.log(Warn, msg, fields)
+}
+
+func (l *Logger) Error(msg string, fields ...Field) {
+	l.log(Error, msg, fields)
+}
+
+func (l *Logger) log(level Level, msg string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	buf := &bytes.Buffer{}
+	now := time.Now()
+	switch l.out.format {
+	case JSON:
+		writeJSON(buf, now, level, msg, l.fields, fields)
+	default:
+		writeConsole(buf, now, level, msg, l.fields, fields)
+	}
+	l.out.mu.Lock()
+	l.out.w.Write(buf.Bytes())
+	l.out.mu.Unlock()
+}
+
+func writeJSON(buf *bytes.Buffer, t time.Time, level Level, msg string, fieldSets ...[]Field) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, t.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, msg)
+	for _, fields := range fieldSets {
+		for _, f := range fields {
+			buf.WriteByte(',')
+			writeJSONValue(buf, f.Key)
+			buf.WriteByte(':')
+			writeJSONValue(buf, f.Value)
+		}
+	}
+	buf.WriteString("}\n")
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+func writeConsole(buf *bytes.Buffer, t time.Time, level Level, msg string, fieldSets ...[]Field) {
+	fmt.Fprintf(buf, "%s %-5s %s", t.Format("2006-01-02T15:04:05.000"), strings.ToUpper(level.String()), msg)
+	for _, fields := range fieldSets {
+		for _, f := range fields {
+			fmt.Fprintf(buf, " %s=%v", f.Key, f.Value)
+		}
+	}
+	buf.WriteByte('\n')
+}
+
+func New(w io.Writer, level Level, format Format) *Logger {
+	return &Logger{
+		out: &output{
+			w:      w,
+			level:  level,
+			format: format,
+		},
+	}
+}
+
+// Nop returns a logger that discards everything
+func Nop() *Logger {
+	return New(ioutil.Discard, Error+1, Console)
+}
+
+// FromConfig creates a logger writing to the configured file or to stderr
+func FromConfig(cfg config.LoggerConfig) (*Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	format, err := ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	var w io.Writer = os.Stderr
+	if cfg.Output != "" {
+		f, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	return New(w, level, format), nil
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoggerJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(buf, Info, JSON).With(F("component", "test"))
+	log.Debug("hidden")
+	log.Warn("failed", F("error", errors.New("boom")), F("n", 3))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("wrote %d lines, want 1: %q", len(lines), buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{
+		"level":     "warn",
+		"msg":       "failed",
+		"component": "test",
+		"error":     "boom",
+		"n":         float64(3),
+	} {
+		if entry[key] != want {
+			t.Errorf("%s = %v, want %v", key, entry[key], want)
+		}
+	}
+}
+
+func TestLoggerWithDoesNotShareFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	base := New(buf, Debug, Console).With(F("a", 1))
+	base.With(F("b", 2))
+	base.With(F("c", 3)).Info("msg")
+	if out := buf.String(); !strings.Contains(out, "INFO  msg a=1 c=3\n") || strings.Contains(out, "b=2") {
+		t.Fatalf("console line = %q", out)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if l, err := ParseLevel("WARN"); err != nil || l != Warn {
+		t.Fatalf("ParseLevel(WARN) = %v, %v", l, err)
+	}
+	if l, err := ParseLevel(""); err != nil || l != Info {
+		t.Fatalf("ParseLevel() = %v, %v", l, err)
+	}
+	if _, err := ParseLevel("trace"); err == nil {
+		t.Fatal("unknown level parsed")
+	}
+	if f, err := ParseFormat("json"); err != nil || f != JSON {
+		t.Fatalf("ParseFormat(json) = %v, %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatal("unknown format parsed")
+	}
+}
+
+func TestNopDiscardsEverything(t *testing.T) {
+	if Nop().Enabled(Error) {
+		t.Fatal("nop logger writes errors")
+	}
+}
@@ -2,6 +2,7 @@ package mertics
 
 import (
 	"sync"
+	"time"
 )
 
 type avg struct {
@@ -13,18 +14,87 @@ func (a *avg) add(f float64) {
 	a.cnt++
 }
 
+// upper bounds of the duration histogram buckets, the last bucket is unbounded
+var durationBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+type histogram struct {
+	counts []int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func (h *histogram) add(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]int64, len(durationBuckets)+1)
+	}
+	i := 0
+	for i < len(durationBuckets) && d > durationBuckets[i] {
+		i++
+	}
+	h.counts[i]++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+type Bucket struct {
+	// upper bound, empty for the unbounded bucket
+	Le    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+// Histogram is the distribution of a duration, bucket counts are cumulative
+type Histogram struct {
+	Count   int64    `json:"count"`
+	Sum     float64  `json:"sum_ms"`
+	Mean    float64  `json:"mean_ms"`
+	Max     float64  `json:"max_ms"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+func (h *histogram) snapshot() Histogram {
+	var s Histogram
+	for i, c := range h.counts {
+		s.Count += c
+		b := Bucket{Count: s.Count}
+		if i < len(durationBuckets) {
+			b.Le = durationBuckets[i].String()
+		}
+		s.Buckets = append(s.Buckets, b)
+	}
+	s.Sum = float64(h.sum) / float64(time.Millisecond)
+	if s.Count > 0 {
+		s.Mean = s.Sum / float64(s.Count)
+	}
+	s.Max = float64(h.max) / float64(time.Millisecond)
+	return s
+}
+
 type Snapshot struct {
-	Counters map[string]float64 `json:"counters"`
-	Max      map[string]float64 `json:"max"`
-	Average  map[string]float64 `json:"average"`
+	Counters   map[string]float64   `json:"counters"`
+	Max        map[string]float64   `json:"max"`
+	Average    map[string]float64   `json:"average"`
+	Histograms map[string]Histogram `json:"histograms"`
 }
 
 type Metrics struct {
-	enabled  bool
-	counters map[string]float64
-	max      map[string]float64
-	average  map[string]avg
-	mu       sync.RWMutex
+	enabled    bool
+	counters   map[string]float64
+	max        map[string]float64
+	average    map[string]avg
+	histograms map[string]*histogram
+	mu         sync.RWMutex
 }
 
 func (m *Metrics) AddAverage(key string, v float64) {
@@ -63,6 +133,22 @@ func (m *Metrics) AddMax(key string, v float64) {
 	}
 }
 
+// ObserveDuration adds a duration to the histogram of the key
+func (m *Metrics) ObserveDuration(key string, d time.Duration) {
+	if !m.enabled {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.histograms[key]
+	if !ok {
+		h = &histogram{}
+		m.histograms[key] = h
+	}
+	h.add(d)
+}
+
 func (m *Metrics) IncCounter(key string) {
 	if !m.enabled {
 		return
@@ -75,9 +161,10 @@ func (m *Metrics) Snapshot() Snapshot {
 	defer m.mu.Unlock()
 
 	s := Snapshot{
-		Counters: map[string]float64{},
-		Max:      map[string]float64{},
-		Average:  map[string]float64{},
+		Counters:   map[string]float64{},
+		Max:        map[string]float64{},
+		Average:    map[string]float64{},
+		Histograms: map[string]Histogram{},
 	}
 
 	for key, val := range m.max {
@@ -90,15 +177,19 @@ func (m *Metrics) Snapshot() Snapshot {
 	for key, val := range m.counters {
 		s.Counters[key] = val
 	}
+	for key, val := range m.histograms {
+		s.Histograms[key] = val.snapshot()
+	}
 	return s
 }
 
 func New(enabled bool) *Metrics {
 	return &Metrics{
-		enabled:  enabled,
-		counters: map[string]float64{},
-		max:      map[string]float64{},
-		average:  map[string]avg{},
-		mu:       sync.RWMutex{},
+		enabled:    enabled,
+		counters:   map[string]float64{},
+		max:        map[string]float64{},
+		average:    map[string]avg{},
+		histograms: map[string]*histogram{},
+		mu:         sync.RWMutex{},
 	}
 }
@@ -0,0 +1,41 @@
+package mertics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveDuration(t *testing.T) {
+	m := New(true)
+	for _, d := range []time.Duration{time.Millisecond, 3 * time.Millisecond, 20 * time.Millisecond, time.Minute} {
+		m.ObserveDuration("wait", d)
+	}
+	h := m.Snapshot().Histograms["wait"]
+	if h.Count != 4 || h.Max != 60000 {
+		t.Fatalf("count = %d, max = %v ms", h.Count, h.Max)
+	}
+	if h.Sum != 60024 || h.Mean != 15006 {
+		t.Fatalf("sum = %v ms, mean = %v ms", h.Sum, h.Mean)
+	}
+	// cumulative counts, the bounds are inclusive
+	want := map[string]int64{"1ms": 1, "5ms": 2, "10ms": 2, "50ms": 3, "10s": 3, "": 4}
+	if len(h.Buckets) != len(durationBuckets)+1 {
+		t.Fatalf("%d buckets", len(h.Buckets))
+	}
+	for _, b := range h.Buckets {
+		if n, ok := want[b.Le]; ok && b.Count != n {
+			t.Errorf("bucket %q = %d, want %d", b.Le, b.Count, n)
+		}
+	}
+}
+
+func TestDisabledMetricsRecordNothing(t *testing.T) {
+	m := New(false)
+	m.ObserveDuration("wait", time.Second)
+	m.IncCounter("n")
+	m.AddAverage("avg", 1)
+	s := m.Snapshot()
+	if len(s.Histograms) != 0 || len(s.Counters) != 0 || len(s.Average) != 0 {
+		t.Fatalf("disabled metrics recorded %+v", s)
+	}
+}
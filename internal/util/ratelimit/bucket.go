@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var DeadlineReachedErr = errors.New("rate limit wait exceeds deadline")
+
+// Bucket is a token bucket that refills with `rate` tokens per second
+// up to `burst` tokens. A nil bucket never limits.
+type Bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// reserve takes a token and returns how long the caller has to wait for it,
+// the token is given back if it would only be available after the deadline.
+func (b *Bucket) reserve(deadline time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0, true
+	}
+
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	if !deadline.IsZero() && now.Add(wait).After(deadline) {
+		b.tokens++
+		return 0, false
+	}
+	return wait, true
+}
+
+func (b *Bucket) cancel() {
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+// Wait blocks until a token is available and returns the time spent waiting.
+// It gives up right away if the token won't be available before the deadline
+// (zero deadline means none) and returns ctx.Err() if ctx is done first.
+func (b *Bucket) Wait(ctx context.Context, deadline time.Time) (time.Duration, error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	wait, ok := b.reserve(deadline)
+	if !ok {
+		return 0, DeadlineReachedErr
+	}
+	if wait == 0 {
+		return 0, nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return wait, nil
+	case <-ctx.Done():
+		b.cancel()
+		return 0, ctx.Err()
+	}
+}
+
+// New returns a bucket that starts full, or nil if rate is not positive.
+func New(rate float64, burst int) *Bucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucketBurstThenWaits(t *testing.T) {
+	b := New(100, 2)
+	for i := 0; i < 2; i++ {
+		if wait, err := b.Wait(context.Background(), time.Time{}); err != nil || wait != 0 {
+			t.Fatalf("token %d of the burst: wait %v, error %v", i, wait, err)
+		}
+	}
+	wait, err := b.Wait(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait <= 0 || wait > 10*time.Millisecond {
+		t.Fatalf("waited %v for a token at 100 per second", wait)
+	}
+}
+
+func TestBucketDeadline(t *testing.T) {
+	b := New(1, 1)
+	b.Wait(context.Background(), time.Time{})
+	if _, err := b.Wait(context.Background(), time.Now().Add(10*time.Millisecond)); err != DeadlineReachedErr {
+		t.Fatalf("error = %v, want %v", err, DeadlineReachedErr)
+	}
+	// the token given back is still there, the next one comes in a second
+	if wait, _ := b.reserve(time.Time{}); wait < 900*time.Millisecond {
+		t.Fatalf("wait = %v after giving up, want about a second", wait)
+	}
+}
+
+func TestBucketCancelGivesTokenBack(t *testing.T) {
+	b := New(1, 1)
+	b.Wait(context.Background(), time.Time{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Wait(ctx, time.Time{}); err != context.DeadlineExceeded {
+		t.Fatalf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if wait, _ := b.reserve(time.Time{}); wait < 900*time.Millisecond {
+		t.Fatalf("wait = %v after a cancelled wait, want about a second", wait)
+	}
+}
+
+func TestNilBucketNeverLimits(t *testing.T) {
+	b := New(0, 10)
+	if b != nil {
+		t.Fatal("a bucket without a rate limits")
+	}
+	if wait, err := b.Wait(context.Background(), time.Now()); wait != 0 || err != nil {
+		t.Fatalf("nil bucket: wait %v, error %v", wait, err)
+	}
+}
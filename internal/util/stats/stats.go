@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+)
+
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// Variance returns the unbiased sample variance
+func Variance(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	m := Mean(xs)
+	var sum float64
+	for _, x := range xs {
+		sum += (x - m) * (x - m)
+	}
+	return sum / float64(len(xs)-1)
+}
+
+// Welch compares the means of two samples with unequal variances and
+// returns the t statistic and an approximate two-sided p-value, the normal
+// approximation is fine for the sample sizes of a run. p is 1 if either
+// sample is too small.
+func Welch(a, b []float64) (t, p float64) {
+	if len(a) < 2 || len(b) < 2 {
+		return 0, 1
+	}
+	se := math.Sqrt(Variance(a)/float64(len(a)) + Variance(b)/float64(len(b)))
+	if se == 0 {
+		return 0, 1
+	}
+	t = (Mean(a) - Mean(b)) / se
+	return t, math.Erfc(math.Abs(t) / math.Sqrt2)
+}
+
+// Gamma draws from the gamma distribution with the shape and rate using
+// the Marsaglia-Tsang method.
+func Gamma(r *rand.Rand, shape, rate float64) float64 {
+	if shape < 1 {
+		// boost the shape and scale the result back down
+		return Gamma(r, shape+1, rate) * math.Pow(r.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v / rate
+		}
+	}
+}
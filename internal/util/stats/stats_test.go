@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMeanVariance(t *testing.T) {
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	if m := Mean(xs); m != 5 {
+		t.Fatalf("mean = %v, want 5", m)
+	}
+	if v := Variance(xs); math.Abs(v-32.0/7) > 1e-9 {
+		t.Fatalf("variance = %v, want %v", v, 32.0/7)
+	}
+	if Mean(nil) != 0 || Variance([]float64{1}) != 0 {
+		t.Fatal("empty samples have a mean or a variance")
+	}
+}
+
+func TestWelch(t *testing.T) {
+	a := []float64{10, 11, 9, 10, 12, 8, 10, 11, 9, 10}
+	b := []float64{5, 6, 4, 5, 7, 3, 5, 6, 4, 5}
+	tt, p := Welch(a, b)
+	if tt <= 0 || p > 1e-6 {
+		t.Fatalf("clearly different means: t = %v, p = %v", tt, p)
+	}
+	if _, p := Welch(a, a); p != 1 {
+		t.Fatalf("p = %v for the same sample, want 1", p)
+	}
+	if _, p := Welch(a, []float64{1}); p != 1 {
+		t.Fatalf("p = %v for a sample of one, want 1", p)
+	}
+}
+
+func TestGammaMoments(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, c := range []struct{ shape, rate float64 }{{0.5, 2}, {3, 1}, {20, 4}} {
+		xs := make([]float64, 20000)
+		for i := range xs {
+			xs[i] = Gamma(r, c.shape, c.rate)
+		}
+		mean, variance := c.shape/c.rate, c.shape/(c.rate*c.rate)
+		if m := Mean(xs); math.Abs(m-mean) > 0.05*mean {
+			t.Errorf("gamma(%v, %v) mean = %v, want %v", c.shape, c.rate, m, mean)
+		}
+		if v := Variance(xs); math.Abs(v-variance) > 0.1*variance {
+			t.Errorf("gamma(%v, %v) variance = %v, want %v", c.shape, c.rate, v, variance)
+		}
+	}
+}